@@ -2,105 +2,111 @@ package dtls
 
 import "fmt"
 
-type alertLevel byte
+// AlertLevel is the severity of an alert, fatal alerts terminate the
+// connection
+type AlertLevel byte
 
+// AlertLevel enums
 const (
-	alertLevelWarning alertLevel = 1
-	alertLevelFatal   alertLevel = 2
+	AlertLevelWarning AlertLevel = 1
+	AlertLevelFatal   AlertLevel = 2
 )
 
-func (a alertLevel) String() string {
+func (a AlertLevel) String() string {
 	switch a {
-	case alertLevelWarning:
+	case AlertLevelWarning:
 		return "LevelWarning"
-	case alertLevelFatal:
+	case AlertLevelFatal:
 		return "LevelFatal"
 	default:
 		return "Invalid alert level"
 	}
 }
 
-type alertDescription byte
+// AlertDescription tells what an alert is about
+// https://tools.ietf.org/html/rfc5246#section-7.2
+type AlertDescription byte
 
+// AlertDescription enums
 const (
-	alertCloseNotify            alertDescription = 0
-	alertUnexpectedMessage      alertDescription = 10
-	alertBadRecordMac           alertDescription = 20
-	alertDecryptionFailed       alertDescription = 21
-	alertRecordOverflow         alertDescription = 22
-	alertDecompressionFailure   alertDescription = 30
-	alertHandshakeFailure       alertDescription = 40
-	alertNoCertificate          alertDescription = 41
-	alertBadCertificate         alertDescription = 42
-	alertUnsupportedCertificate alertDescription = 43
-	alertCertificateRevoked     alertDescription = 44
-	alertCertificateExpired     alertDescription = 45
-	alertCertificateUnknown     alertDescription = 46
-	alertIllegalParameter       alertDescription = 47
-	alertUnknownCA              alertDescription = 48
-	alertAccessDenied           alertDescription = 49
-	alertDecodeError            alertDescription = 50
-	alertDecryptError           alertDescription = 51
-	alertExportRestriction      alertDescription = 60
-	alertProtocolVersion        alertDescription = 70
-	alertInsufficientSecurity   alertDescription = 71
-	alertInternalError          alertDescription = 80
-	alertUserCanceled           alertDescription = 90
-	alertNoRenegotiation        alertDescription = 100
-	alertUnsupportedExtension   alertDescription = 110
+	AlertCloseNotify            AlertDescription = 0
+	AlertUnexpectedMessage      AlertDescription = 10
+	AlertBadRecordMac           AlertDescription = 20
+	AlertDecryptionFailed       AlertDescription = 21
+	AlertRecordOverflow         AlertDescription = 22
+	AlertDecompressionFailure   AlertDescription = 30
+	AlertHandshakeFailure       AlertDescription = 40
+	AlertNoCertificate          AlertDescription = 41
+	AlertBadCertificate         AlertDescription = 42
+	AlertUnsupportedCertificate AlertDescription = 43
+	AlertCertificateRevoked     AlertDescription = 44
+	AlertCertificateExpired     AlertDescription = 45
+	AlertCertificateUnknown     AlertDescription = 46
+	AlertIllegalParameter       AlertDescription = 47
+	AlertUnknownCA              AlertDescription = 48
+	AlertAccessDenied           AlertDescription = 49
+	AlertDecodeError            AlertDescription = 50
+	AlertDecryptError           AlertDescription = 51
+	AlertExportRestriction      AlertDescription = 60
+	AlertProtocolVersion        AlertDescription = 70
+	AlertInsufficientSecurity   AlertDescription = 71
+	AlertInternalError          AlertDescription = 80
+	AlertUserCanceled           AlertDescription = 90
+	AlertNoRenegotiation        AlertDescription = 100
+	AlertUnsupportedExtension   AlertDescription = 110
 )
 
-func (a alertDescription) String() string {
+func (a AlertDescription) String() string {
 	switch a {
-	case alertCloseNotify:
+	case AlertCloseNotify:
 		return "CloseNotify"
-	case alertUnexpectedMessage:
+	case AlertUnexpectedMessage:
 		return "UnexpectedMessage"
-	case alertBadRecordMac:
+	case AlertBadRecordMac:
 		return "BadRecordMac"
-	case alertDecryptionFailed:
+	case AlertDecryptionFailed:
 		return "DecryptionFailed"
-	case alertRecordOverflow:
+	case AlertRecordOverflow:
 		return "RecordOverflow"
-	case alertDecompressionFailure:
+	case AlertDecompressionFailure:
 		return "DecompressionFailure"
-	case alertHandshakeFailure:
+	case AlertHandshakeFailure:
 		return "HandshakeFailure"
-	case alertNoCertificate:
+	case AlertNoCertificate:
 		return "NoCertificate"
-	case alertBadCertificate:
+	case AlertBadCertificate:
 		return "BadCertificate"
-	case alertUnsupportedCertificate:
+	case AlertUnsupportedCertificate:
 		return "UnsupportedCertificate"
-	case alertCertificateRevoked:
+	case AlertCertificateRevoked:
 		return "CertificateRevoked"
-	case alertCertificateExpired:
+	case AlertCertificateExpired:
 		return "CertificateExpired"
-	case alertCertificateUnknown:
+	case AlertCertificateUnknown:
 		return "CertificateUnknown"
-	case alertIllegalParameter:
+	case AlertIllegalParameter:
 		return "IllegalParameter"
-	case alertUnknownCA:
+	case AlertUnknownCA:
 		return "UnknownCA"
-	case alertAccessDenied:
+	case AlertAccessDenied:
 		return "AccessDenied"
-	case alertDecodeError:
+	case AlertDecodeError:
 		return "DecodeError"
-	case alertDecryptError:
+	case AlertDecryptError:
 		return "DecryptError"
-	case alertExportRestriction:
+	case AlertExportRestriction:
 		return "ExportRestriction"
-	case alertProtocolVersion:
+	case AlertProtocolVersion:
 		return "ProtocolVersion"
-	case alertInsufficientSecurity:
+	case AlertInsufficientSecurity:
 		return "InsufficientSecurity"
-	case alertInternalError:
+	case AlertInternalError:
 		return "InternalError"
-	case alertUserCanceled:
+	case AlertUserCanceled:
 		return "UserCanceled"
-	case alertNoRenegotiation:
+	case AlertNoRenegotiation:
 		return "NoRenegotiation"
-	case alertUnsupportedExtension:
+	case AlertUnsupportedExtension:
 		return "UnsupportedExtension"
 	default:
 		return "Invalid alert description"
@@ -118,8 +124,8 @@ func (a alertDescription) String() string {
 // compressed, as specified by the current connection state.
 // https://tools.ietf.org/html/rfc5246#section-7.2
 type alert struct {
-	alertLevel       alertLevel
-	alertDescription alertDescription
+	alertLevel       AlertLevel
+	alertDescription AlertDescription
 }
 
 func (a alert) contentType() contentType {
@@ -135,8 +141,8 @@ func (a *alert) Unmarshal(data []byte) error {
 		return errBufferTooSmall
 	}
 
-	a.alertLevel = alertLevel(data[0])
-	a.alertDescription = alertDescription(data[1])
+	a.alertLevel = AlertLevel(data[0])
+	a.alertDescription = AlertDescription(data[1])
 	return nil
 }
 
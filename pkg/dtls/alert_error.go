@@ -0,0 +1,65 @@
+package dtls
+
+import "fmt"
+
+// AlertError is returned by the handshake and Read when the peer sent a
+// fatal alert
+type AlertError struct {
+	Level       AlertLevel
+	Description AlertDescription
+}
+
+func (a *AlertError) Error() string {
+	return fmt.Sprintf("dtls: received alert %s: %s", a.Level, a.Description)
+}
+
+// errorAlerts maps the errors that abort a connection to the fatal alert
+// sent to the peer, anything else is an internal_error
+// https://tools.ietf.org/html/rfc5246#section-7.2.2
+var errorAlerts = map[error]AlertDescription{
+	errBufferTooSmall:          AlertDecodeError,
+	errDTLSPacketInvalidLength: AlertDecodeError,
+	errLengthMismatch:          AlertDecodeError,
+	errNotEnoughDataForCid:     AlertDecodeError,
+	errSessionIDTooLong:        AlertDecodeError,
+	errCookieTooLong:           AlertDecodeError,
+
+	errInvalidContentType:       AlertUnexpectedMessage,
+	errNotImplemented:           AlertUnexpectedMessage,
+	errServerKeyExchangeMissing: AlertUnexpectedMessage,
+
+	errInvalidCipherSuite:        AlertIllegalParameter,
+	errInvalidCompressionMethod:  AlertIllegalParameter,
+	errInvalidEllipticCurveType:  AlertIllegalParameter,
+	errInvalidNamedCurve:         AlertIllegalParameter,
+	errInvalidHashAlgorithm:      AlertIllegalParameter,
+	errInvalidSignatureAlgorithm: AlertIllegalParameter,
+	errInvalidExtensionType:      AlertIllegalParameter,
+	errResumedSessionEMSMismatch: AlertIllegalParameter,
+	errCookieMismatch:            AlertIllegalParameter,
+
+	errServerSentUnrequestedEMS:    AlertUnsupportedExtension,
+	errServerSentUnrequestedEtM:    AlertUnsupportedExtension,
+	errServerSentUnrequestedSRTP:   AlertUnsupportedExtension,
+	errServerSentUnrequestedTicket: AlertUnsupportedExtension,
+
+	errCipherSuiteNoIntersection:    AlertHandshakeFailure,
+	errClientRequiredButNoServerEMS: AlertHandshakeFailure,
+	errServerRequiredButNoClientEMS: AlertHandshakeFailure,
+
+	errVerifyDataMismatch:    AlertDecryptError,
+	errKeySignatureMismatch:  AlertDecryptError,
+	errInvalidECDSASignature: AlertDecryptError,
+
+	errInvalidMAC:     AlertBadRecordMac,
+	errInvalidPadding: AlertBadRecordMac,
+}
+
+// alertForError returns the fatal alert that tells the peer why err
+// aborted the connection
+func alertForError(err error) AlertDescription {
+	if desc, ok := errorAlerts[err]; ok {
+		return desc
+	}
+	return AlertInternalError
+}
@@ -0,0 +1,23 @@
+package dtls
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAlertForError(t *testing.T) {
+	for _, test := range []struct {
+		err  error
+		want AlertDescription
+	}{
+		{errLengthMismatch, AlertDecodeError},
+		{errVerifyDataMismatch, AlertDecryptError},
+		{errCipherSuiteNoIntersection, AlertHandshakeFailure},
+		{errServerSentUnrequestedEMS, AlertUnsupportedExtension},
+		{errors.New("dtls: something else"), AlertInternalError},
+	} {
+		if got := alertForError(test.err); got != test.want {
+			t.Errorf("alertForError(%v): got %s, want %s", test.err, got, test.want)
+		}
+	}
+}
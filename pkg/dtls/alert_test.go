@@ -16,8 +16,8 @@ func TestAlert(t *testing.T) {
 			Name: "Valid Alert",
 			Data: []byte{0x02, 0x0A},
 			Want: &alert{
-				alertLevel:       alertLevelFatal,
-				alertDescription: alertUnexpectedMessage,
+				alertLevel:       AlertLevelFatal,
+				alertDescription: AlertUnexpectedMessage,
 			},
 		},
 		{
@@ -5,13 +5,46 @@ import (
 	"hash"
 )
 
-type cipherSuiteID uint16
+// CipherSuiteID is an ID for our supported CipherSuites
+type CipherSuiteID uint16
+
+// Supported Cipher Suites
+const (
+	// AES-128-GCM-SHA256
+	TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256 CipherSuiteID = 0xc02b // nolint
+	TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256   CipherSuiteID = 0xc02f // nolint
+
+	// AES-256-GCM-SHA384
+	TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384 CipherSuiteID = 0xc02c // nolint
+	TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384   CipherSuiteID = 0xc030 // nolint
+
+	// AES-256-CBC-SHA
+	TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA CipherSuiteID = 0xc00a // nolint
+	TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA   CipherSuiteID = 0x0035 // nolint
+
+	// PSK, see Config.PSK
+	TLS_PSK_WITH_AES_128_GCM_SHA256       CipherSuiteID = 0x00a8 // nolint
+	TLS_PSK_WITH_AES_128_CCM_8            CipherSuiteID = 0xc0a8 // nolint
+	TLS_ECDHE_PSK_WITH_AES_128_CBC_SHA256 CipherSuiteID = 0xc037 // nolint
+)
 
 type cipherSuite interface {
-	ID() cipherSuiteID
+	ID() CipherSuiteID
+
+	// The client certificate type the cipherSuite requires. PSK
+	// cipherSuites are not authenticated with a certificate, they return 0
+	// which is never matched against a clientCertificateType.
 	certificateType() clientCertificateType
 	hashFunc() func() hash.Hash
 
+	// Hash used for the signatures (ServerKeyExchange, CertificateVerify)
+	// exchanged while negotiating this cipherSuite
+	hashAlgorithm() HashAlgorithm
+
+	// How the premaster secret is agreed on, and so which handshake
+	// messages are exchanged while negotiating this cipherSuite
+	keyExchangeAlgorithm() keyExchangeAlgorithm
+
 	// Generate the internal encryption state
 	init(masterSecret, clientRandom, serverRandom []byte, isClient bool) error
 
@@ -19,19 +52,38 @@ type cipherSuite interface {
 	decrypt(in []byte) ([]byte, error)
 }
 
+// encryptThenMacCipherSuite is implemented by the cipherSuites using a block
+// cipher, these can switch to Encrypt-then-MAC once the peer agrees to it.
+// It must be called before init.
+// https://tools.ietf.org/html/rfc7366
+type encryptThenMacCipherSuite interface {
+	cipherSuite
+	setEncryptThenMac()
+}
+
 // Taken from https://www.iana.org/assignments/tls-parameters/tls-parameters.xml
 // A cipherSuite is a specific combination of key agreement, cipher and MAC
 // function.
-func cipherSuiteForID(id cipherSuiteID) cipherSuite {
+func cipherSuiteForID(id CipherSuiteID) cipherSuite {
 	switch id {
 	case cipherSuiteTLSEcdheEcdsaWithAes128GcmSha256{}.ID():
 		return &cipherSuiteTLSEcdheEcdsaWithAes128GcmSha256{}
 	case cipherSuiteTLSEcdheRsaWithAes128GcmSha256{}.ID():
 		return &cipherSuiteTLSEcdheRsaWithAes128GcmSha256{}
+	case cipherSuiteTLSEcdheEcdsaWithAes256GcmSha384{}.ID():
+		return &cipherSuiteTLSEcdheEcdsaWithAes256GcmSha384{}
+	case cipherSuiteTLSEcdheRsaWithAes256GcmSha384{}.ID():
+		return &cipherSuiteTLSEcdheRsaWithAes256GcmSha384{}
 	case cipherSuiteTLSEcdheEcdsaWithAes256CbcSha{}.ID():
 		return &cipherSuiteTLSEcdheEcdsaWithAes256CbcSha{}
 	case cipherSuiteTLSEcdheRsaWithAes256CbcSha{}.ID():
 		return &cipherSuiteTLSEcdheRsaWithAes256CbcSha{}
+	case cipherSuiteTLSPskWithAes128GcmSha256{}.ID():
+		return &cipherSuiteTLSPskWithAes128GcmSha256{}
+	case cipherSuiteTLSPskWithAes128Ccm8{}.ID():
+		return &cipherSuiteTLSPskWithAes128Ccm8{}
+	case cipherSuiteTLSEcdhePskWithAes128CbcSha256{}.ID():
+		return &cipherSuiteTLSEcdhePskWithAes128CbcSha256{}
 	}
 
 	return nil
@@ -41,28 +93,84 @@ func cipherSuiteForID(id cipherSuiteID) cipherSuite {
 // Preferred at the bottom
 func clientCipherSuites() []cipherSuite {
 	return []cipherSuite{
+		&cipherSuiteTLSPskWithAes128Ccm8{},
+		&cipherSuiteTLSEcdhePskWithAes128CbcSha256{},
+		&cipherSuiteTLSPskWithAes128GcmSha256{},
 		&cipherSuiteTLSEcdheRsaWithAes256CbcSha{},
 		&cipherSuiteTLSEcdheEcdsaWithAes256CbcSha{},
+		&cipherSuiteTLSEcdheRsaWithAes256GcmSha384{},
 		&cipherSuiteTLSEcdheRsaWithAes128GcmSha256{},
+		&cipherSuiteTLSEcdheEcdsaWithAes256GcmSha384{},
 		&cipherSuiteTLSEcdheEcdsaWithAes128GcmSha256{},
 	}
 }
 
-// CipherSuites we support as a server
-// func serverCipherSuites() []cipherSuite {
-// 	return []cipherSuite{
-// 		&cipherSuiteTLSEcdheEcdsaWithAes128GcmSha256{},
-// 	}
-// }
+// parseCipherSuites turns the IDs from the Config into cipherSuites,
+// the first ID is the most preferred one. As with clientCipherSuites
+// the returned list has the preferred cipherSuite at the bottom.
+// If no IDs are given all the cipherSuites we support are returned.
+func parseCipherSuites(ids []CipherSuiteID) ([]cipherSuite, error) {
+	if len(ids) == 0 {
+		return clientCipherSuites(), nil
+	}
+
+	cipherSuites := []cipherSuite{}
+	for i := len(ids); i > 0; i-- {
+		c := cipherSuiteForID(ids[i-1])
+		if c == nil {
+			return nil, errInvalidCipherSuite
+		}
+		cipherSuites = append(cipherSuites, c)
+	}
+	return cipherSuites, nil
+}
+
+// filterCipherSuites drops the cipherSuites that can't be negotiated
+// with the credentials in the Config. PSK cipherSuites need Config.PSK,
+// as a server the others need a Certificate matching the cipherSuite.
+func filterCipherSuites(cipherSuites []cipherSuite, config *Config, isClient bool) []cipherSuite {
+	filtered := []cipherSuite{}
+	for _, c := range cipherSuites {
+		switch {
+		case c.keyExchangeAlgorithm().isPSK():
+			if config.PSK == nil {
+				continue
+			}
+		case !isClient:
+			if config.Certificate == nil || c.certificateType() != clientCertificateTypeECDSASign {
+				continue
+			}
+		}
+		filtered = append(filtered, c)
+	}
+	return filtered
+}
+
+// serverSelectCipherSuite picks the first cipherSuite offered by the
+// client that we are configured for
+func serverSelectCipherSuite(offered, local []cipherSuite) (cipherSuite, error) {
+	for _, o := range offered {
+		for _, l := range local {
+			if o.ID() == l.ID() {
+				return o, nil
+			}
+		}
+	}
+	return nil, errCipherSuiteNoIntersection
+}
 
 func decodeCipherSuites(buf []byte) ([]cipherSuite, error) {
 	if len(buf) < 2 {
 		return nil, errDTLSPacketInvalidLength
 	}
-	cipherSuitesCount := int(binary.BigEndian.Uint16(buf[0:])) / 2
+	declaredLen := int(binary.BigEndian.Uint16(buf[0:]))
+	if declaredLen%2 != 0 || len(buf) < 2+declaredLen {
+		return nil, errDTLSPacketInvalidLength
+	}
+	cipherSuitesCount := declaredLen / 2
 	rtrn := []cipherSuite{}
 	for i := 0; i < cipherSuitesCount; i++ {
-		id := cipherSuiteID(binary.BigEndian.Uint16(buf[(i*2)+2:]))
+		id := CipherSuiteID(binary.BigEndian.Uint16(buf[(i*2)+2:]))
 		if c := cipherSuiteForID(id); c != nil {
 			rtrn = append(rtrn, c)
 		}
@@ -1,6 +1,7 @@
 package dtls
 
 import (
+	"crypto/x509"
 	"testing"
 )
 
@@ -22,3 +23,99 @@ func TestDecodeCipherSuites(t *testing.T) {
 	}
 
 }
+
+func TestParseCipherSuites(t *testing.T) {
+	cipherSuites, err := parseCipherSuites([]CipherSuiteID{TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Preferred at the bottom, same as clientCipherSuites
+	expected := []CipherSuiteID{TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384}
+	if len(cipherSuites) != len(expected) {
+		t.Fatalf("parseCipherSuites: got %d cipher suites, want %d", len(cipherSuites), len(expected))
+	}
+	for i, c := range cipherSuites {
+		if c.ID() != expected[i] {
+			t.Errorf("parseCipherSuites[%d]: got %#04x, want %#04x", i, c.ID(), expected[i])
+		}
+	}
+
+	if _, err := parseCipherSuites([]CipherSuiteID{0x0000}); err != errInvalidCipherSuite {
+		t.Errorf("parseCipherSuites with unknown ID: expected '%v' actual '%v'", errInvalidCipherSuite, err)
+	}
+}
+
+func TestFilterCipherSuites(t *testing.T) {
+	all := clientCipherSuites()
+
+	testCases := []struct {
+		name     string
+		config   *Config
+		isClient bool
+		expected []CipherSuiteID
+	}{
+		{
+			name:     "Client without PSK",
+			config:   &Config{},
+			isClient: true,
+			expected: []CipherSuiteID{
+				TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA, TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+				TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384, TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+				TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			},
+		},
+		{
+			name:     "Server with Certificate",
+			config:   &Config{Certificate: &x509.Certificate{}},
+			isClient: false,
+			expected: []CipherSuiteID{
+				TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA, TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			},
+		},
+		{
+			name:     "Server with PSK",
+			config:   &Config{PSK: func([]byte) ([]byte, error) { return nil, nil }},
+			isClient: false,
+			expected: []CipherSuiteID{
+				TLS_PSK_WITH_AES_128_CCM_8, TLS_ECDHE_PSK_WITH_AES_128_CBC_SHA256, TLS_PSK_WITH_AES_128_GCM_SHA256,
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		filtered := filterCipherSuites(all, testCase.config, testCase.isClient)
+		if len(filtered) != len(testCase.expected) {
+			t.Errorf("filterCipherSuites %s: got %d cipher suites, want %d", testCase.name, len(filtered), len(testCase.expected))
+			continue
+		}
+		for i, c := range filtered {
+			if c.ID() != testCase.expected[i] {
+				t.Errorf("filterCipherSuites %s[%d]: got %#04x, want %#04x", testCase.name, i, c.ID(), testCase.expected[i])
+			}
+		}
+	}
+}
+
+func TestServerSelectCipherSuite(t *testing.T) {
+	offered := []cipherSuite{
+		&cipherSuiteTLSEcdheRsaWithAes256GcmSha384{},
+		&cipherSuiteTLSEcdheEcdsaWithAes128GcmSha256{},
+		&cipherSuiteTLSEcdheEcdsaWithAes256GcmSha384{},
+	}
+	local := []cipherSuite{
+		&cipherSuiteTLSPskWithAes128GcmSha256{},
+		&cipherSuiteTLSEcdheEcdsaWithAes256GcmSha384{},
+	}
+
+	c, err := serverSelectCipherSuite(offered, local)
+	if err != nil {
+		t.Fatal(err)
+	} else if c.ID() != TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384 {
+		t.Errorf("serverSelectCipherSuite: got %#04x, want %#04x", c.ID(), TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384)
+	}
+
+	if _, err := serverSelectCipherSuite(offered[1:2], local); err != errCipherSuiteNoIntersection {
+		t.Errorf("serverSelectCipherSuite without intersection: expected '%v' actual '%v'", errCipherSuiteNoIntersection, err)
+	}
+}
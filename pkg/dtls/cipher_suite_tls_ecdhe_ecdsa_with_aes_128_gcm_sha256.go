@@ -7,21 +7,29 @@ import (
 )
 
 type cipherSuiteTLSEcdheEcdsaWithAes128GcmSha256 struct {
-	gcm *cryptoGCM
+	gcm *cryptoAEAD
 }
 
 func (c cipherSuiteTLSEcdheEcdsaWithAes128GcmSha256) certificateType() clientCertificateType {
 	return clientCertificateTypeECDSASign
 }
 
-func (c cipherSuiteTLSEcdheEcdsaWithAes128GcmSha256) ID() cipherSuiteID {
-	return 0xc02b
+func (c cipherSuiteTLSEcdheEcdsaWithAes128GcmSha256) ID() CipherSuiteID {
+	return TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256
 }
 
 func (c cipherSuiteTLSEcdheEcdsaWithAes128GcmSha256) hashFunc() func() hash.Hash {
 	return sha256.New
 }
 
+func (c cipherSuiteTLSEcdheEcdsaWithAes128GcmSha256) hashAlgorithm() HashAlgorithm {
+	return HashAlgorithmSHA256
+}
+
+func (c cipherSuiteTLSEcdheEcdsaWithAes128GcmSha256) keyExchangeAlgorithm() keyExchangeAlgorithm {
+	return keyExchangeAlgorithmEcdhe
+}
+
 func (c *cipherSuiteTLSEcdheEcdsaWithAes128GcmSha256) init(masterSecret, clientRandom, serverRandom []byte, isClient bool) error {
 	const (
 		prfMacLen = 0
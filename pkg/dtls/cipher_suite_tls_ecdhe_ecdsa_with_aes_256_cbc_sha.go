@@ -1,27 +1,41 @@
 package dtls
 
 import (
+	"crypto/sha1" // #nosec
 	"crypto/sha256"
 	"errors"
 	"hash"
 )
 
 type cipherSuiteTLSEcdheEcdsaWithAes256CbcSha struct {
-	cbc *cryptoCBC
+	cbc            *cryptoCBC
+	encryptThenMac bool
 }
 
 func (c cipherSuiteTLSEcdheEcdsaWithAes256CbcSha) certificateType() clientCertificateType {
 	return clientCertificateTypeECDSASign
 }
 
-func (c cipherSuiteTLSEcdheEcdsaWithAes256CbcSha) ID() cipherSuiteID {
-	return 0xc00a
+func (c cipherSuiteTLSEcdheEcdsaWithAes256CbcSha) ID() CipherSuiteID {
+	return TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA
 }
 
 func (c cipherSuiteTLSEcdheEcdsaWithAes256CbcSha) hashFunc() func() hash.Hash {
 	return sha256.New
 }
 
+func (c cipherSuiteTLSEcdheEcdsaWithAes256CbcSha) hashAlgorithm() HashAlgorithm {
+	return HashAlgorithmSHA256
+}
+
+func (c cipherSuiteTLSEcdheEcdsaWithAes256CbcSha) keyExchangeAlgorithm() keyExchangeAlgorithm {
+	return keyExchangeAlgorithmEcdhe
+}
+
+func (c *cipherSuiteTLSEcdheEcdsaWithAes256CbcSha) setEncryptThenMac() {
+	c.encryptThenMac = true
+}
+
 func (c *cipherSuiteTLSEcdheEcdsaWithAes256CbcSha) init(masterSecret, clientRandom, serverRandom []byte, isClient bool) error {
 	const (
 		prfMacLen = 20
@@ -38,11 +52,13 @@ func (c *cipherSuiteTLSEcdheEcdsaWithAes256CbcSha) init(masterSecret, clientRand
 		c.cbc, err = newCryptoCBC(
 			keys.clientWriteKey, keys.clientWriteIV, keys.clientMACKey,
 			keys.serverWriteKey, keys.serverWriteIV, keys.serverMACKey,
+			sha1.New, c.encryptThenMac,
 		)
 	} else {
 		c.cbc, err = newCryptoCBC(
 			keys.serverWriteKey, keys.serverWriteIV, keys.serverMACKey,
 			keys.clientWriteKey, keys.clientWriteIV, keys.clientMACKey,
+			sha1.New, c.encryptThenMac,
 		)
 	}
 
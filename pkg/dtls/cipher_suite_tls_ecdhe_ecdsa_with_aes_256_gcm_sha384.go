@@ -0,0 +1,68 @@
+package dtls
+
+import (
+	"crypto/sha512"
+	"errors"
+	"hash"
+)
+
+type cipherSuiteTLSEcdheEcdsaWithAes256GcmSha384 struct {
+	gcm *cryptoAEAD
+}
+
+func (c cipherSuiteTLSEcdheEcdsaWithAes256GcmSha384) certificateType() clientCertificateType {
+	return clientCertificateTypeECDSASign
+}
+
+func (c cipherSuiteTLSEcdheEcdsaWithAes256GcmSha384) ID() CipherSuiteID {
+	return TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384
+}
+
+func (c cipherSuiteTLSEcdheEcdsaWithAes256GcmSha384) hashFunc() func() hash.Hash {
+	return sha512.New384
+}
+
+func (c cipherSuiteTLSEcdheEcdsaWithAes256GcmSha384) hashAlgorithm() HashAlgorithm {
+	return HashAlgorithmSHA384
+}
+
+func (c cipherSuiteTLSEcdheEcdsaWithAes256GcmSha384) keyExchangeAlgorithm() keyExchangeAlgorithm {
+	return keyExchangeAlgorithmEcdhe
+}
+
+func (c *cipherSuiteTLSEcdheEcdsaWithAes256GcmSha384) init(masterSecret, clientRandom, serverRandom []byte, isClient bool) error {
+	const (
+		prfMacLen = 0
+		prfKeyLen = 32
+		prfIvLen  = 4
+	)
+
+	keys, err := prfEncryptionKeys(masterSecret, clientRandom, serverRandom, prfMacLen, prfKeyLen, prfIvLen, c.hashFunc())
+	if err != nil {
+		return err
+	}
+
+	if isClient {
+		c.gcm, err = newCryptoGCM(keys.clientWriteKey, keys.clientWriteIV, keys.serverWriteKey, keys.serverWriteIV)
+	} else {
+		c.gcm, err = newCryptoGCM(keys.serverWriteKey, keys.serverWriteIV, keys.clientWriteKey, keys.clientWriteIV)
+	}
+
+	return err
+}
+
+func (c *cipherSuiteTLSEcdheEcdsaWithAes256GcmSha384) encrypt(pkt *recordLayer, raw []byte) ([]byte, error) {
+	if c.gcm == nil {
+		return nil, errors.New("CipherSuite has not been initalized, unable to encrypt")
+	}
+
+	return c.gcm.encrypt(pkt, raw)
+}
+
+func (c *cipherSuiteTLSEcdheEcdsaWithAes256GcmSha384) decrypt(raw []byte) ([]byte, error) {
+	if c.gcm == nil {
+		return nil, errors.New("CipherSuite has not been initalized, unable to decrypt ")
+	}
+
+	return c.gcm.decrypt(raw)
+}
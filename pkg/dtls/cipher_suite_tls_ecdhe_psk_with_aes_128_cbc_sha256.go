@@ -0,0 +1,81 @@
+package dtls
+
+import (
+	"crypto/sha256"
+	"errors"
+	"hash"
+)
+
+type cipherSuiteTLSEcdhePskWithAes128CbcSha256 struct {
+	cbc            *cryptoCBC
+	encryptThenMac bool
+}
+
+func (c cipherSuiteTLSEcdhePskWithAes128CbcSha256) certificateType() clientCertificateType {
+	return 0
+}
+
+func (c cipherSuiteTLSEcdhePskWithAes128CbcSha256) ID() CipherSuiteID {
+	return TLS_ECDHE_PSK_WITH_AES_128_CBC_SHA256
+}
+
+func (c cipherSuiteTLSEcdhePskWithAes128CbcSha256) hashFunc() func() hash.Hash {
+	return sha256.New
+}
+
+func (c cipherSuiteTLSEcdhePskWithAes128CbcSha256) hashAlgorithm() HashAlgorithm {
+	return HashAlgorithmSHA256
+}
+
+func (c cipherSuiteTLSEcdhePskWithAes128CbcSha256) keyExchangeAlgorithm() keyExchangeAlgorithm {
+	return keyExchangeAlgorithmEcdhePsk
+}
+
+func (c *cipherSuiteTLSEcdhePskWithAes128CbcSha256) setEncryptThenMac() {
+	c.encryptThenMac = true
+}
+
+func (c *cipherSuiteTLSEcdhePskWithAes128CbcSha256) init(masterSecret, clientRandom, serverRandom []byte, isClient bool) error {
+	const (
+		prfMacLen = 32
+		prfKeyLen = 16
+		prfIvLen  = 16
+	)
+
+	keys, err := prfEncryptionKeys(masterSecret, clientRandom, serverRandom, prfMacLen, prfKeyLen, prfIvLen, c.hashFunc())
+	if err != nil {
+		return err
+	}
+
+	if isClient {
+		c.cbc, err = newCryptoCBC(
+			keys.clientWriteKey, keys.clientWriteIV, keys.clientMACKey,
+			keys.serverWriteKey, keys.serverWriteIV, keys.serverMACKey,
+			sha256.New, c.encryptThenMac,
+		)
+	} else {
+		c.cbc, err = newCryptoCBC(
+			keys.serverWriteKey, keys.serverWriteIV, keys.serverMACKey,
+			keys.clientWriteKey, keys.clientWriteIV, keys.clientMACKey,
+			sha256.New, c.encryptThenMac,
+		)
+	}
+
+	return err
+}
+
+func (c *cipherSuiteTLSEcdhePskWithAes128CbcSha256) encrypt(pkt *recordLayer, raw []byte) ([]byte, error) {
+	if c.cbc == nil {
+		return nil, errors.New("CipherSuite has not been initalized, unable to encrypt")
+	}
+
+	return c.cbc.encrypt(pkt, raw)
+}
+
+func (c *cipherSuiteTLSEcdhePskWithAes128CbcSha256) decrypt(raw []byte) ([]byte, error) {
+	if c.cbc == nil {
+		return nil, errors.New("CipherSuite has not been initalized, unable to decrypt ")
+	}
+
+	return c.cbc.decrypt(raw)
+}
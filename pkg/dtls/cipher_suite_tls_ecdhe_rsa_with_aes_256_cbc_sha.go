@@ -8,6 +8,6 @@ func (c cipherSuiteTLSEcdheRsaWithAes256CbcSha) certificateType() clientCertific
 	return clientCertificateTypeRSASign
 }
 
-func (c cipherSuiteTLSEcdheRsaWithAes256CbcSha) ID() cipherSuiteID {
-	return 0x0035
+func (c cipherSuiteTLSEcdheRsaWithAes256CbcSha) ID() CipherSuiteID {
+	return TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA
 }
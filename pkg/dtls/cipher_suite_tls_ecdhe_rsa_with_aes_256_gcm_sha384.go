@@ -0,0 +1,13 @@
+package dtls
+
+type cipherSuiteTLSEcdheRsaWithAes256GcmSha384 struct {
+	cipherSuiteTLSEcdheEcdsaWithAes256GcmSha384
+}
+
+func (c cipherSuiteTLSEcdheRsaWithAes256GcmSha384) certificateType() clientCertificateType {
+	return clientCertificateTypeRSASign
+}
+
+func (c cipherSuiteTLSEcdheRsaWithAes256GcmSha384) ID() CipherSuiteID {
+	return TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384
+}
@@ -0,0 +1,17 @@
+package dtls
+
+type cipherSuiteTLSPskWithAes128GcmSha256 struct {
+	cipherSuiteTLSEcdheEcdsaWithAes128GcmSha256
+}
+
+func (c cipherSuiteTLSPskWithAes128GcmSha256) certificateType() clientCertificateType {
+	return 0
+}
+
+func (c cipherSuiteTLSPskWithAes128GcmSha256) ID() CipherSuiteID {
+	return TLS_PSK_WITH_AES_128_GCM_SHA256
+}
+
+func (c cipherSuiteTLSPskWithAes128GcmSha256) keyExchangeAlgorithm() keyExchangeAlgorithm {
+	return keyExchangeAlgorithmPsk
+}
@@ -2,6 +2,7 @@ package dtls
 
 import (
 	"bytes"
+	"crypto/rand"
 	"fmt"
 )
 
@@ -21,6 +22,9 @@ func clientHandshakeHandler(c *Conn) error {
 
 	for out, fragEpoch := c.fragmentBuffer.pop(); out != nil; out, fragEpoch = c.fragmentBuffer.pop() {
 		rawHandshake := &handshake{}
+		if c.cipherSuite != nil {
+			rawHandshake.keyExchangeAlgorithm = c.cipherSuite.keyExchangeAlgorithm()
+		}
 		if err := rawHandshake.Unmarshal(out); err != nil {
 			return err
 		}
@@ -45,6 +49,9 @@ func clientHandshakeHandler(c *Conn) error {
 				}
 				fallthrough
 			case flight3:
+				if _, err := serverSelectCipherSuite([]cipherSuite{h.cipherSuite}, c.localCipherSuites); err != nil {
+					return errInvalidCipherSuite
+				}
 				c.cipherSuite = h.cipherSuite
 				c.remoteRandom = h.random
 
@@ -53,11 +60,56 @@ func clientHandshakeHandler(c *Conn) error {
 					case *extensionConnectionId:
 						if len(e.connectionId) > 0 {
 							c.scid = e.connectionId
+							c.logCid.Store(c.scid)
+						}
+					case *extensionExtendedMasterSecret:
+						if c.localExtendedMasterSecret == DisableExtendedMasterSecret {
+							return errServerSentUnrequestedEMS
+						}
+						c.extendedMasterSecret = true
+					case *extensionEncryptThenMac:
+						cbc, ok := c.cipherSuite.(encryptThenMacCipherSuite)
+						if !ok {
+							return errServerSentUnrequestedEtM
+						}
+						cbc.setEncryptThenMac()
+						c.encryptThenMac = true
+					case *extensionSessionTicket:
+						if c.sessionCache == nil {
+							return errServerSentUnrequestedTicket
 						}
+						c.sendSessionTicket = true
+					case *extensionUseSRTP:
+						// The server echoes exactly one of the offered profiles
+						if len(e.protectionProfiles) != 1 {
+							return errServerSentUnrequestedSRTP
+						}
+						profile, ok := selectSRTPProtectionProfile(c.localSRTPProfiles, e.protectionProfiles)
+						if !ok {
+							return errServerSentUnrequestedSRTP
+						}
+						c.srtpProtectionProfile = profile
+					}
+				}
+
+				if c.localExtendedMasterSecret == RequireExtendedMasterSecret && !c.extendedMasterSecret {
+					return errClientRequiredButNoServerEMS
+				}
+
+				if c.session != nil && len(h.sessionID) != 0 && bytes.Equal(c.session.ID, h.sessionID) {
+					if err := clientResumeSession(c); err != nil {
+						return err
 					}
+				} else {
+					c.sessionID = append([]byte{}, h.sessionID...)
 				}
 			}
 
+		case *handshakeMessageNewSessionTicket:
+			if c.sendSessionTicket {
+				c.sessionTicket = h.ticket
+			}
+
 		case *handshakeMessageCertificate:
 			if c.currFlight.get() == flight3 {
 				c.remoteCertificate = h.certificate
@@ -65,7 +117,15 @@ func clientHandshakeHandler(c *Conn) error {
 
 		case *handshakeMessageServerKeyExchange:
 			if c.currFlight.get() == flight3 && c.cipherSuite != nil {
+				c.remotePSKIdentityHint = h.identityHint
+				if !c.cipherSuite.keyExchangeAlgorithm().isECDHE() {
+					break
+				}
+
 				c.remoteKeypair = &namedCurveKeypair{h.namedCurve, h.publicKey, nil}
+				if c.cipherSuite.keyExchangeAlgorithm() != keyExchangeAlgorithmEcdhe {
+					break
+				}
 
 				clientRandom, err := c.localRandom.Marshal()
 				if err != nil {
@@ -76,44 +136,55 @@ func clientHandshakeHandler(c *Conn) error {
 					return err
 				}
 
-				c.localKeypair, err = generateKeypair(h.namedCurve)
-				if err != nil {
+				expectedHash := valueKeySignature(clientRandom, serverRandom, h.publicKey, h.namedCurve, h.hashAlgorithm)
+				if err := verifyKeySignature(expectedHash, h.signature, c.remoteCertificate); err != nil {
 					return err
 				}
+			}
 
-				preMasterSecret, err := prfPreMasterSecret(c.remoteKeypair.publicKey, c.localKeypair.privateKey, c.localKeypair.curve)
-				if err != nil {
-					return err
-				}
+		case *handshakeMessageCertificateRequest:
+			c.remoteRequestedCertificate = true
 
-				c.masterSecret, err = prfMasterSecret(preMasterSecret, clientRandom, serverRandom, c.cipherSuite.hashFunc())
+		case *handshakeMessageServerHelloDone:
+			if c.currFlight.get() == flight3 && c.cipherSuite != nil {
+				preMasterSecret, err := clientPreMasterSecret(c)
 				if err != nil {
 					return err
 				}
+				c.preMasterSecret = preMasterSecret
 
-				if err := c.cipherSuite.init(c.masterSecret, clientRandom, serverRandom /* isClient */, true); err != nil {
+				c.localSequenceNumber++
+				if err := c.currFlight.set(flight5); err != nil {
 					return err
 				}
+			}
 
-				expectedHash := valueKeySignature(clientRandom, serverRandom, h.publicKey, h.namedCurve, h.hashAlgorithm)
-				if err := verifyKeySignature(expectedHash, h.signature, c.remoteCertificate); err != nil {
+		case *handshakeMessageFinished:
+			switch {
+			case c.currFlight.get() == flight3 && c.resumed:
+				// Abbreviated handshake, the server finishes first
+				expectedVerifyData, err := prfVerifyDataServer(c.masterSecret, c.handshakeCache.combinedHandshake(clientExcludeRules(c), true), c.cipherSuite.hashFunc())
+				if err != nil {
 					return err
 				}
-			}
+				if !bytes.Equal(expectedVerifyData, h.verifyData) {
+					return errVerifyDataMismatch
+				}
 
-		case *handshakeMessageCertificateRequest:
-			c.remoteRequestedCertificate = true
+				if len(c.sessionTicket) != 0 {
+					if err := clientStoreSession(c); err != nil {
+						return err
+					}
+				}
 
-		case *handshakeMessageServerHelloDone:
-			if c.currFlight.get() == flight3 {
+				// Our Finished takes sequenceNumber 0 of the new epoch
+				c.localEpoch = 1
 				c.localSequenceNumber++
 				if err := c.currFlight.set(flight5); err != nil {
 					return err
 				}
-			}
 
-		case *handshakeMessageFinished:
-			if c.currFlight.get() == flight5 {
+			case c.currFlight.get() == flight5:
 				c.localEpoch = 1
 				c.localSequenceNumber = 1
 
@@ -124,6 +195,12 @@ func clientHandshakeHandler(c *Conn) error {
 				if !bytes.Equal(expectedVerifyData, h.verifyData) {
 					return errVerifyDataMismatch
 				}
+
+				if c.sessionCache != nil && (len(c.sessionID) != 0 || len(c.sessionTicket) != 0) {
+					if err := clientStoreSession(c); err != nil {
+						return err
+					}
+				}
 				c.signalHandshakeComplete()
 			}
 
@@ -135,12 +212,171 @@ func clientHandshakeHandler(c *Conn) error {
 	return nil
 }
 
+// clientPreMasterSecret is computed once the whole server flight has been
+// received, the PSK cipherSuites may not send a ServerKeyExchange
+func clientPreMasterSecret(c *Conn) ([]byte, error) {
+	var preMasterSecret []byte
+	if c.cipherSuite.keyExchangeAlgorithm().isECDHE() {
+		if c.remoteKeypair == nil {
+			return nil, errServerKeyExchangeMissing
+		}
+
+		var err error
+		c.localKeypair, err = generateKeypair(c.remoteKeypair.curve)
+		if err != nil {
+			return nil, err
+		}
+
+		preMasterSecret, err = prfPreMasterSecret(c.remoteKeypair.publicKey, c.localKeypair.privateKey, c.localKeypair.curve)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if c.cipherSuite.keyExchangeAlgorithm().isPSK() {
+		psk, err := c.localPSKCallback(c.remotePSKIdentityHint)
+		if err != nil {
+			return nil, err
+		}
+		preMasterSecret = prfPSKPreMasterSecret(psk, preMasterSecret)
+	}
+
+	return preMasterSecret, nil
+}
+
+// clientSessionKey identifies the server in the SessionCache
+func clientSessionKey(c *Conn) string {
+	if addr := c.nextConn.RemoteAddr(); addr != nil {
+		return addr.String()
+	}
+	return ""
+}
+
+// clientStoreSession caches the Session for the next connection to the same
+// server. A resumption with a ticket is recognised by the server echoing
+// the session ID, so one is made up if the server didn't assign it
+// https://tools.ietf.org/html/rfc5077#section-3.4
+func clientStoreSession(c *Conn) error {
+	session := newSession(c)
+	session.Ticket = c.sessionTicket
+	if len(session.ID) == 0 {
+		session.ID = make([]byte, sessionIDLength)
+		if _, err := rand.Read(session.ID); err != nil {
+			return err
+		}
+	}
+
+	c.sessionCache.Put(clientSessionKey(c), session)
+	return nil
+}
+
+// clientResumeSession restores the Session the server agreed to resume, the
+// cipherSuite and master secret derivation must not have changed since
+// https://tools.ietf.org/html/rfc7627#section-5.3
+func clientResumeSession(c *Conn) error {
+	if c.cipherSuite.ID() != c.session.CipherSuiteID {
+		return errInvalidCipherSuite
+	} else if c.extendedMasterSecret != c.session.ExtendedMasterSecret {
+		return errResumedSessionEMSMismatch
+	}
+
+	c.resumed = true
+	c.sessionID = c.session.ID
+	c.masterSecret = c.session.Secret
+
+	clientRandom, err := c.localRandom.Marshal()
+	if err != nil {
+		return err
+	}
+	serverRandom, err := c.remoteRandom.Marshal()
+	if err != nil {
+		return err
+	}
+	if err = c.writeKeyLog(clientRandom, c.masterSecret); err != nil {
+		return err
+	}
+	return c.cipherSuite.init(c.masterSecret, clientRandom, serverRandom /* isClient */, true)
+}
+
+// clientInitCipherSuite derives the master secret, this has to wait until the
+// ClientKeyExchange is in the handshakeCache as the Extended Master Secret
+// session hash covers it
+func clientInitCipherSuite(c *Conn) error {
+	clientRandom, err := c.localRandom.Marshal()
+	if err != nil {
+		return err
+	}
+	serverRandom, err := c.remoteRandom.Marshal()
+	if err != nil {
+		return err
+	}
+
+	if c.extendedMasterSecret {
+		sessionHash, err := c.handshakeCache.sessionHash(c.cipherSuite.hashFunc(), clientExcludeRules(c))
+		if err != nil {
+			return err
+		}
+
+		c.masterSecret, err = prfExtendedMasterSecret(c.preMasterSecret, sessionHash, c.cipherSuite.hashFunc())
+		if err != nil {
+			return err
+		}
+	} else {
+		c.masterSecret, err = prfMasterSecret(c.preMasterSecret, clientRandom, serverRandom, c.cipherSuite.hashFunc())
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = c.writeKeyLog(clientRandom, c.masterSecret); err != nil {
+		return err
+	}
+	return c.cipherSuite.init(c.masterSecret, clientRandom, serverRandom /* isClient */, true)
+}
+
 func clientFlightHandler(c *Conn) (bool, error) {
 	switch c.currFlight.get() {
 	case flight1:
 		fallthrough
 	case flight3:
 		c.lock.RLock()
+
+		extensions := []extension{
+			&extensionSupportedEllipticCurves{
+				ellipticCurves: []NamedCurve{NamedCurveX25519, NamedCurveP256},
+			},
+			&extensionSupportedPointFormats{
+				pointFormats: []ellipticCurvePointFormat{ellipticCurvePointFormatUncompressed},
+			},
+			&extensionConnectionId{},
+		}
+		if c.localExtendedMasterSecret != DisableExtendedMasterSecret {
+			extensions = append(extensions, &extensionExtendedMasterSecret{})
+		}
+		for _, s := range c.localCipherSuites {
+			if _, ok := s.(encryptThenMacCipherSuite); ok {
+				extensions = append(extensions, &extensionEncryptThenMac{})
+				break
+			}
+		}
+
+		if len(c.localSRTPProfiles) != 0 {
+			extensions = append(extensions, &extensionUseSRTP{
+				protectionProfiles: c.localSRTPProfiles,
+			})
+		}
+
+		var sessionID []byte
+		if c.session != nil {
+			sessionID = c.session.ID
+		}
+		if c.sessionCache != nil {
+			sessionTicket := &extensionSessionTicket{}
+			if c.session != nil {
+				sessionTicket.ticket = c.session.Ticket
+			}
+			extensions = append(extensions, sessionTicket)
+		}
+
 		c.internalSend(&recordLayer{
 			recordLayerHeader: recordLayerHeader{
 				sequenceNumber:  c.localSequenceNumber,
@@ -153,24 +389,28 @@ func clientFlightHandler(c *Conn) (bool, error) {
 				},
 				handshakeMessage: &handshakeMessageClientHello{
 					version:            protocolVersion1_2,
+					sessionID:          sessionID,
 					cookie:             c.cookie,
 					random:             c.localRandom,
-					cipherSuites:       clientCipherSuites(),
+					cipherSuites:       c.localCipherSuites,
 					compressionMethods: defaultCompressionMethods,
-					extensions: []extension{
-						&extensionSupportedEllipticCurves{
-							ellipticCurves: []namedCurve{namedCurveX25519, namedCurveP256},
-						},
-						&extensionSupportedPointFormats{
-							pointFormats: []ellipticCurvePointFormat{ellipticCurvePointFormatUncompressed},
-						},
-						&extensionConnectionId{},
-					},
+					extensions:         extensions,
 				}},
 		}, false)
 		c.lock.RUnlock()
 	case flight5:
 		c.lock.RLock()
+		if c.resumed {
+			// Abbreviated handshake, ours is the last flight
+			err := clientSendFinished(c, c.localSequenceNumber)
+			c.lock.RUnlock()
+			if err != nil {
+				return false, err
+			}
+			c.signalHandshakeComplete()
+			return true, nil
+		}
+
 		// TODO: Better way to end handshake
 		if c.remoteEpoch != 0 {
 			// Handshake is done
@@ -178,6 +418,14 @@ func clientFlightHandler(c *Conn) (bool, error) {
 			return true, nil
 		}
 
+		clientKeyExchange := &handshakeMessageClientKeyExchange{
+			keyExchangeAlgorithm: c.cipherSuite.keyExchangeAlgorithm(),
+			identity:             c.localPSKIdentity,
+		}
+		if c.cipherSuite.keyExchangeAlgorithm().isECDHE() {
+			clientKeyExchange.publicKey = c.localKeypair.publicKey
+		}
+
 		sequenceNumber := c.localSequenceNumber
 		if c.remoteRequestedCertificate {
 			c.internalSend(&recordLayer{
@@ -207,16 +455,23 @@ func clientFlightHandler(c *Conn) (bool, error) {
 				handshakeHeader: handshakeHeader{
 					messageSequence: uint16(sequenceNumber),
 				},
-				handshakeMessage: &handshakeMessageClientKeyExchange{
-					publicKey: c.localKeypair.publicKey,
-				}},
+				handshakeMessage: clientKeyExchange,
+			},
 		}, false)
 		sequenceNumber++
 
+		if len(c.masterSecret) == 0 {
+			if err := clientInitCipherSuite(c); err != nil {
+				c.lock.RUnlock()
+				return false, err
+			}
+		}
+
 		if c.remoteRequestedCertificate {
 			if len(c.localCertificateVerify) == 0 {
-				certVerify, err := generateCertificateVerify(c.handshakeCache.combinedHandshake(clientExcludeRules(c), false), c.localPrivateKey)
+				certVerify, err := generateCertificateVerify(c.handshakeCache.combinedHandshake(clientExcludeRules(c), false), c.localPrivateKey, c.cipherSuite.hashAlgorithm())
 				if err != nil {
+					c.lock.RUnlock()
 					return false, err
 				}
 				c.localCertificateVerify = certVerify
@@ -233,7 +488,7 @@ func clientFlightHandler(c *Conn) (bool, error) {
 						messageSequence: uint16(sequenceNumber),
 					},
 					handshakeMessage: &handshakeMessageCertificateVerify{
-						hashAlgorithm:      HashAlgorithmSHA256,
+						hashAlgorithm:      c.cipherSuite.hashAlgorithm(),
 						signatureAlgorithm: signatureAlgorithmECDSA,
 						signature:          c.localCertificateVerify,
 					}},
@@ -241,41 +496,51 @@ func clientFlightHandler(c *Conn) (bool, error) {
 			sequenceNumber++
 		}
 
-		c.internalSend(&recordLayer{
-			recordLayerHeader: recordLayerHeader{
-				sequenceNumber:  sequenceNumber,
-				protocolVersion: protocolVersion1_2,
-			},
-			content: &changeCipherSpec{},
-		}, false)
-
-		if len(c.localVerifyData) == 0 {
-			var err error
-			c.localVerifyData, err = prfVerifyDataClient(c.masterSecret, c.handshakeCache.combinedHandshake(clientExcludeRules(c), false), c.cipherSuite.hashFunc())
-			if err != nil {
-				return false, err
-			}
-		}
-
-		// TODO: Fix hard-coded epoch & sequenceNumber, taking retransmitting into account.
-		c.internalSend(&recordLayer{
-			recordLayerHeader: recordLayerHeader{
-				epoch:           1,
-				sequenceNumber:  0, // sequenceNumber restarts per epoch
-				protocolVersion: protocolVersion1_2,
-			},
-			content: &handshake{
-				// sequenceNumber and messageSequence line up, may need to be re-evaluated
-				handshakeHeader: handshakeHeader{
-					messageSequence: uint16(sequenceNumber), // KeyExchange + 1
-				},
-				handshakeMessage: &handshakeMessageFinished{
-					verifyData: c.localVerifyData,
-				}},
-		}, true)
+		err := clientSendFinished(c, sequenceNumber)
 		c.lock.RUnlock()
+		if err != nil {
+			return false, err
+		}
 	default:
 		return false, fmt.Errorf("Unhandled flight %s", c.currFlight.get())
 	}
 	return false, nil
 }
+
+// clientSendFinished sends the ChangeCipherSpec and Finished that end a
+// client flight, sequenceNumber is the one of the ChangeCipherSpec
+func clientSendFinished(c *Conn, sequenceNumber uint64) error {
+	c.internalSend(&recordLayer{
+		recordLayerHeader: recordLayerHeader{
+			sequenceNumber:  sequenceNumber,
+			protocolVersion: protocolVersion1_2,
+		},
+		content: &changeCipherSpec{},
+	}, false)
+
+	if len(c.localVerifyData) == 0 {
+		var err error
+		c.localVerifyData, err = prfVerifyDataClient(c.masterSecret, c.handshakeCache.combinedHandshake(clientExcludeRules(c), false), c.cipherSuite.hashFunc())
+		if err != nil {
+			return err
+		}
+	}
+
+	// TODO: Fix hard-coded epoch & sequenceNumber, taking retransmitting into account.
+	c.internalSend(&recordLayer{
+		recordLayerHeader: recordLayerHeader{
+			epoch:           1,
+			sequenceNumber:  0, // sequenceNumber restarts per epoch
+			protocolVersion: protocolVersion1_2,
+		},
+		content: &handshake{
+			// sequenceNumber and messageSequence line up, may need to be re-evaluated
+			handshakeHeader: handshakeHeader{
+				messageSequence: uint16(sequenceNumber),
+			},
+			handshakeMessage: &handshakeMessageFinished{
+				verifyData: c.localVerifyData,
+			}},
+	}, true)
+	return nil
+}
@@ -23,6 +23,9 @@ func decodeCompressionMethods(buf []byte) ([]*compressionMethod, error) {
 		return nil, errDTLSPacketInvalidLength
 	}
 	compressionMethodsCount := int(buf[0])
+	if len(buf) < 1+compressionMethodsCount {
+		return nil, errDTLSPacketInvalidLength
+	}
 	c := []*compressionMethod{}
 	for i := 0; i < compressionMethodsCount; i++ {
 		id := compressionMethodID(buf[i+1])
@@ -3,6 +3,10 @@ package dtls
 import (
 	"crypto"
 	"crypto/x509"
+	"io"
+	"time"
+
+	"github.com/thomas-fossati/dtls/pkg/logging"
 )
 
 // Config is used to configure a DTLS client or server.
@@ -10,4 +14,149 @@ import (
 type Config struct {
 	Certificate *x509.Certificate
 	PrivateKey  crypto.PrivateKey
+
+	// CipherSuites is a list of supported cipher suites, the first one
+	// being the most preferred. If CipherSuites is nil, every cipher
+	// suite implemented by this package is enabled.
+	CipherSuites []CipherSuiteID
+
+	// PSK sets the pre-shared key used by this DTLS connection and
+	// enables the PSK cipher suites (RFC 4279). As a client it is called
+	// with the identity hint sent by the server, which may be nil. As a
+	// server it is called with the identity sent by the client.
+	PSK func(hint []byte) ([]byte, error)
+
+	// PSKIdentityHint is sent by a server to help the client select
+	// which PSK to use. It may be nil.
+	PSKIdentityHint []byte
+
+	// PSKIdentity is sent by a client to tell the server which PSK it
+	// is using.
+	PSKIdentity []byte
+
+	// ExtendedMasterSecret decides whether the Extended Master Secret
+	// extension (RFC 7627) is requested, required or disabled. It
+	// defaults to RequestExtendedMasterSecret.
+	ExtendedMasterSecret ExtendedMasterSecretType
+
+	// SessionCache enables session resumption. A client offers the
+	// session it last negotiated with the same server address, a server
+	// assigns session IDs and resumes the sessions it finds. It may be
+	// shared between connections, and between servers to resume
+	// sessions across instances. If nil, sessions are never resumed.
+	SessionCache SessionCache
+
+	// SessionTicketKeys enables stateless session resumption with session
+	// tickets (RFC 5077) on a server. The first key seals new tickets, and
+	// tickets sealed with any of the keys are accepted, so keys are rotated
+	// by prepending a new one and later dropping the oldest. Servers that
+	// share the keys resume each other's sessions. A client asks for
+	// tickets whenever SessionCache is set.
+	SessionTicketKeys [][32]byte
+
+	// CookieGenerator and CookieVerifier make and check the cookies of the
+	// HelloVerifyRequests a server sends, they must be set together. Servers
+	// that share them, such as CookieHMACs with the same key, verify each
+	// other's cookies. If nil, a CookieHMAC with a random key is used.
+	CookieGenerator CookieGenerator
+	CookieVerifier  CookieVerifier
+
+	// InsecureSkipHelloVerify makes a server answer the first ClientHello
+	// right away instead of sending a HelloVerifyRequest. This gives up the
+	// protection cookies provide against spoofed source addresses, it should
+	// only be used on trusted networks.
+	InsecureSkipHelloVerify bool
+
+	// ReplayProtectionWindow is the number of records the anti-replay
+	// window covers (RFC 6347 section 4.1.2.6), it is rounded up to a
+	// multiple of 64. Records left of the window or received before are
+	// discarded. If 0, a window of 64 records is used, if negative replay
+	// protection is disabled.
+	ReplayProtectionWindow int
+
+	// ReceiveQueueSize is the number of received records that are kept
+	// until they are read. If < 1, 64 records are kept.
+	ReceiveQueueSize int
+
+	// HandshakeTimeout is how long the handshake may take before the
+	// connection is closed. If 0, 30 seconds is used.
+	HandshakeTimeout time.Duration
+
+	// AcceptBacklog is the number of connections a Listener handshakes
+	// concurrently, together with the handshaken connections that wait
+	// for Accept. New clients are ignored while the backlog is full. If
+	// < 1, 128 connections are kept.
+	AcceptBacklog int
+
+	// ReceiveQueueOverflow decides what happens to records that are
+	// received while the queue is full. It defaults to
+	// QueueOverflowDropNewest.
+	ReceiveQueueOverflow QueueOverflowPolicy
+
+	// PartialReads lets Read return the start of a record that doesn't fit
+	// in p, the following Reads return the rest. Otherwise Read fails and
+	// the record is kept for a Read with a larger buffer.
+	PartialReads bool
+
+	// SRTPProtectionProfiles are the profiles offered or accepted with the
+	// use_srtp extension (RFC 5764), most preferred first. A server picks
+	// the first of its profiles the client offered. If empty, use_srtp is
+	// not negotiated.
+	SRTPProtectionProfiles []SRTPProtectionProfile
+
+	// KeyLogWriter receives the master secret of every handshake in the
+	// NSS key log format, which Wireshark uses to decrypt captures. It may
+	// be shared between connections. Logging secrets compromises the
+	// security of the connections, use it for debugging only.
+	KeyLogWriter io.Writer
+
+	// LoggerFactory creates the loggers of the connections and listeners,
+	// their messages are scoped to the remote address and connection ID.
+	// If nil, nothing is logged.
+	LoggerFactory logging.LoggerFactory
+
+	// Tracer receives the handshake messages, flight changes,
+	// retransmission timeouts and dropped records of the connections. See
+	// NewJSONTracer. If nil, nothing is traced.
+	Tracer Tracer
 }
+
+// QueueOverflowPolicy decides what happens to received records that
+// don't fit in the receive queue
+type QueueOverflowPolicy int
+
+// QueueOverflowPolicy enums
+const (
+	// QueueOverflowDropNewest discards the records received while the
+	// queue is full
+	QueueOverflowDropNewest QueueOverflowPolicy = iota
+
+	// QueueOverflowDropOldest discards the oldest queued record to make
+	// room for the new one
+	QueueOverflowDropOldest
+
+	// QueueOverflowBlock stops reading from the underlying conn until
+	// there is room. Nothing is lost, but alerts and handshake
+	// retransmissions wait for the reader too.
+	QueueOverflowBlock
+)
+
+// ExtendedMasterSecretType declares the policy the client and server
+// follow for the Extended Master Secret extension
+type ExtendedMasterSecretType int
+
+// ExtendedMasterSecretType enums
+const (
+	// RequestExtendedMasterSecret uses the Extended Master Secret when
+	// the peer supports it, and falls back to the legacy derivation
+	// otherwise
+	RequestExtendedMasterSecret ExtendedMasterSecretType = iota
+
+	// RequireExtendedMasterSecret aborts the handshake if the peer
+	// doesn't support the Extended Master Secret
+	RequireExtendedMasterSecret
+
+	// DisableExtendedMasterSecret never negotiates the Extended Master
+	// Secret
+	DisableExtendedMasterSecret
+)
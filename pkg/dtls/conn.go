@@ -3,42 +3,84 @@ package dtls
 import (
 	"crypto"
 	"crypto/ecdsa"
-	"crypto/rand"
 	"crypto/x509"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/thomas-fossati/dtls/pkg/dtls/internal/deadline"
+	"github.com/thomas-fossati/dtls/pkg/logging"
 )
 
 const initialTickerInterval = time.Second
-const cookieLength = 20
-const defaultNamedCurve = namedCurveX25519
+const defaultNamedCurve = NamedCurveX25519
+const defaultReceiveQueueSize = 64
+const defaultHandshakeTimeout = 30 * time.Second
+
+// maxPlaintextLength is the most application data a record carries
+// https://tools.ietf.org/html/rfc5246#section-6.2.1
+const maxPlaintextLength = 1 << 14
+
+// receiveBufferSize holds the largest UDP datagram
+const receiveBufferSize = 65535
 
 var invalidKeyingLabels = map[string]bool{
 	"client finished": true,
 	"server finished": true,
 	"master secret":   true,
 	"key expansion":   true,
+
+	"extended master secret": true,
 }
 
 type handshakeMessageHandler func(*Conn) error
 type flightHandler func(*Conn) (bool, error)
 
+// NetConnWithCid is a net.Conn that is told the connection ID the server
+// receives on, once it is negotiated. A listener uses it to route the
+// records carrying the CID to the Conn, whatever address they come from.
 type NetConnWithCid interface {
 	net.Conn
 	PromoteToCidConnection([]byte) error
 }
 
+// plainConn adapts a net.Conn that only receives from its remote, where
+// the CID needs no routing
+type plainConn struct {
+	net.Conn
+}
+
+func (plainConn) PromoteToCidConnection([]byte) error {
+	return nil
+}
+
+// withCid returns conn as a NetConnWithCid, wrapping it if needed
+func withCid(conn net.Conn) NetConnWithCid {
+	switch c := conn.(type) {
+	case nil:
+		return nil
+	case NetConnWithCid:
+		return c
+	default:
+		return plainConn{conn}
+	}
+}
+
 // Conn represents a DTLS connection
 type Conn struct {
+	replayStats ReplayStats // accessed atomically, first for 64-bit alignment
+	stats       connStats   // accessed atomically
+
 	lock           sync.RWMutex    // Internal lock (must not be public)
 	nextConn       NetConnWithCid  // Embedded Conn, typically a udpconn we read/write from
 	fragmentBuffer *fragmentBuffer // out-of-order and missing fragment handling
 	handshakeCache *handshakeCache // caching of handshake messages for verifyData generation
 	decrypted      chan []byte     // Decrypted Application Data, pull by calling `Read`
+	closed         chan struct{}   // closed when the connection stops
 	workerTicker   *time.Ticker
 
 	isClient                   bool
@@ -47,26 +89,73 @@ type Conn struct {
 	localSequenceNumber        uint64 // uint48
 
 	currFlight                          *flight
-	cipherSuite                         cipherSuite // nil if a cipherSuite hasn't been chosen
-	namedCurve                          namedCurve
+	localCipherSuites                   []cipherSuite // cipherSuites we are willing to negotiate
+	cipherSuite                         cipherSuite   // nil if a cipherSuite hasn't been chosen
+	namedCurve                          NamedCurve
 	localRandom, remoteRandom           handshakeRandom
 	localCertificate, remoteCertificate *x509.Certificate
 	localPrivateKey                     crypto.PrivateKey
 	localKeypair, remoteKeypair         *namedCurveKeypair
 	cookie                              []byte
+	cookieGenerator                     CookieGenerator // server only
+	cookieVerifier                      CookieVerifier  // server only
+	skipHelloVerify                     bool            // server only
+
+	localPSKCallback      func([]byte) ([]byte, error)
+	localPSKIdentityHint  []byte // sent by the server
+	localPSKIdentity      []byte // sent by the client
+	remotePSKIdentityHint []byte
 
 	localCertificateVerify []byte // cache CertificateVerify
 	localVerifyData        []byte // cached VerifyData
 
-	masterSecret []byte
+	localExtendedMasterSecret ExtendedMasterSecretType // policy from the Config
+	extendedMasterSecret      bool                     // negotiated with the remote
+	encryptThenMac            bool                     // negotiated with the remote, CBC cipherSuites only
+
+	preMasterSecret, masterSecret []byte
+
+	sessionCache SessionCache
+	session      *Session // the Session a client offered to resume
+	sessionID    []byte   // assigned by the server, empty if not resumable
+	resumed      bool     // abbreviated handshake
+
+	sessionTicketKeys []*sessionTicketKey // server only
+	sessionTicket     []byte              // issued by the server
+	sendSessionTicket bool                // NewSessionTicket announced in the ServerHello
+
+	localSRTPProfiles     []SRTPProtectionProfile // profiles from the Config
+	srtpProtectionProfile SRTPProtectionProfile   // zero if use_srtp wasn't negotiated
+	handshakeDuration     time.Duration
+	keyLogWriter          io.Writer
 
 	handshakeMessageHandler handshakeMessageHandler
 	flightHandler           flightHandler
 	handshakeCompleted      chan bool
+	handshakeCompletedOnce  sync.Once // the handshake timer and the read loop both signal
+
+	connErr  atomic.Value
+	stopOnce sync.Once
 
-	connErr atomic.Value
+	writeClosed     bool          // we sent close_notify
+	remoteClosed    chan struct{} // closed when the peer sent close_notify
+	remoteCloseOnce sync.Once
 
 	ccid, scid []byte // client and server connection identifiers
+
+	replayDetector *replayDetector // nil if replay protection is disabled
+
+	receiveQueueOverflow QueueOverflowPolicy
+	partialReads         bool
+	readLock             sync.Mutex
+	pendingRead          []byte // rest of a partially read record
+
+	readDeadline  *deadline.Deadline
+	writeDeadline *deadline.Deadline
+
+	log    logging.LeveledLogger
+	logCid atomic.Value // []byte, the CID the messages are scoped to
+	tracer Tracer
 }
 
 func createConn(nextConn NetConnWithCid, flightHandler flightHandler, handshakeMessageHandler handshakeMessageHandler, config *Config, isClient bool) (*Conn, error) {
@@ -82,43 +171,113 @@ func createConn(nextConn NetConnWithCid, flightHandler flightHandler, handshakeM
 		return nil, errNilNextConn
 	}
 
+	localCipherSuites, err := parseCipherSuites(config.CipherSuites)
+	if err != nil {
+		return nil, err
+	}
+	localCipherSuites = filterCipherSuites(localCipherSuites, config, isClient)
+	if len(localCipherSuites) == 0 {
+		return nil, errNoAvailableCipherSuites
+	}
+
+	sessionTicketKeys, err := newSessionTicketKeys(config.SessionTicketKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range config.SRTPProtectionProfiles {
+		if _, ok := srtpProtectionProfiles[p]; !ok {
+			return nil, errInvalidSRTPProfile
+		}
+	}
+
+	receiveQueueSize := config.ReceiveQueueSize
+	if receiveQueueSize < 1 {
+		receiveQueueSize = defaultReceiveQueueSize
+	}
+	handshakeTimeout := config.HandshakeTimeout
+	if handshakeTimeout == 0 {
+		handshakeTimeout = defaultHandshakeTimeout
+	}
+
 	c := &Conn{
-		isClient:                isClient,
-		nextConn:                nextConn,
-		currFlight:              newFlight(isClient),
-		fragmentBuffer:          newFragmentBuffer(),
-		handshakeCache:          newHandshakeCache(),
-		handshakeMessageHandler: handshakeMessageHandler,
-		flightHandler:           flightHandler,
-		localCertificate:        config.Certificate,
-		localPrivateKey:         config.PrivateKey,
-		namedCurve:              defaultNamedCurve,
-
-		decrypted:          make(chan []byte),
+		isClient:                  isClient,
+		nextConn:                  nextConn,
+		currFlight:                newFlight(isClient),
+		fragmentBuffer:            newFragmentBuffer(),
+		handshakeCache:            newHandshakeCache(),
+		handshakeMessageHandler:   handshakeMessageHandler,
+		flightHandler:             flightHandler,
+		localCertificate:          config.Certificate,
+		localPrivateKey:           config.PrivateKey,
+		localCipherSuites:         localCipherSuites,
+		localPSKCallback:          config.PSK,
+		localPSKIdentityHint:      config.PSKIdentityHint,
+		localPSKIdentity:          config.PSKIdentity,
+		localExtendedMasterSecret: config.ExtendedMasterSecret,
+		sessionCache:              config.SessionCache,
+		sessionTicketKeys:         sessionTicketKeys,
+		localSRTPProfiles:         config.SRTPProtectionProfiles,
+		keyLogWriter:              config.KeyLogWriter,
+		receiveQueueOverflow:      config.ReceiveQueueOverflow,
+		partialReads:              config.PartialReads,
+		namedCurve:                defaultNamedCurve,
+		tracer:                    config.Tracer,
+
+		decrypted:          make(chan []byte, receiveQueueSize),
+		closed:             make(chan struct{}),
 		workerTicker:       time.NewTicker(initialTickerInterval),
 		handshakeCompleted: make(chan bool),
+		remoteClosed:       make(chan struct{}),
+		readDeadline:       deadline.New(),
+		writeDeadline:      deadline.New(),
+	}
+	c.log = logging.WithPrefix(logging.NewLogger(config.LoggerFactory, "dtls"), c.logPrefix)
+	if c.tracer != nil {
+		c.currFlight.onChange = c.traceFlightChanged
 	}
-	err := c.localRandom.populate()
+	if config.ReplayProtectionWindow == 0 {
+		c.replayDetector = newReplayDetector(defaultReplayProtectionWindow)
+	} else if config.ReplayProtectionWindow > 0 {
+		c.replayDetector = newReplayDetector(config.ReplayProtectionWindow)
+	}
+
+	err = c.localRandom.populate()
 	if err != nil {
 		return nil, err
 	}
 	if !isClient {
-		c.cookie = make([]byte, cookieLength)
-		if _, err = rand.Read(c.cookie); err != nil {
+		if c.cookieGenerator, c.cookieVerifier, err = configCookies(config); err != nil {
 			return nil, err
 		}
+		c.skipHelloVerify = config.InsecureSkipHelloVerify
+	} else if c.sessionCache != nil {
+		if s, ok := c.sessionCache.Get(clientSessionKey(c)); ok {
+			c.session = s
+		}
 	}
 
 	// Trigger outbound
+	handshakeStart := time.Now()
 	c.startHandshakeOutbound()
 
+	// A peer that stops halfway must not hold the Conn, and the Listener
+	// backlog slot, forever
+	handshakeTimer := time.AfterFunc(handshakeTimeout, func() {
+		select {
+		case <-c.handshakeCompleted:
+		default:
+			c.stopWithError(errHandshakeTimeout)
+		}
+	})
+
 	// Handle inbound
 	go func() {
 		defer func() {
 			close(c.decrypted)
 		}()
 
-		b := make([]byte, 8192)
+		b := make([]byte, receiveBufferSize)
 		for {
 			i, err := c.nextConn.Read(b)
 			if err != nil {
@@ -129,14 +288,22 @@ func createConn(nextConn NetConnWithCid, flightHandler flightHandler, handshakeM
 			}
 
 			if err := c.handleIncoming(b[:i]); err != nil {
-				c.stopWithError(err)
+				c.abort(err)
 				return
 			}
 		}
 	}()
 
 	<-c.handshakeCompleted
-	return c, c.getConnErr()
+	handshakeTimer.Stop()
+	c.lock.Lock()
+	c.handshakeDuration = time.Since(handshakeStart)
+	c.lock.Unlock()
+	if err := c.getConnErr(); err != nil {
+		return c, err
+	}
+	c.log.Debugf("handshake completed in %v", c.handshakeDuration)
+	return c, nil
 }
 
 // Dial connects to the given network address and establishes a DTLS connection on top
@@ -145,45 +312,161 @@ func Dial(network string, raddr *net.UDPAddr, config *Config) (*Conn, error) {
 	if err != nil {
 		return nil, err
 	}
-	return Client(ClientUDPConnWithCid{udpConn: pConn}, config)
+	return Client(pConn, config)
 }
 
-// Client establishes a DTLS connection over an existing conn
-func Client(conn NetConnWithCid, config *Config) (*Conn, error) {
-	return createConn(conn, clientFlightHandler, clientHandshakeHandler, config, true)
+// DialWithPacketConn establishes a DTLS connection with raddr over pConn,
+// a socket the caller already owns, such as one selected by ICE or a TURN
+// relay. Packets from other addresses are discarded. The connection then
+// owns pConn, and closes it when it is closed.
+func DialWithPacketConn(pConn net.PacketConn, raddr net.Addr, config *Config) (*Conn, error) {
+	return Client(&packetConn{pConn: pConn, rAddr: raddr}, config)
 }
 
-// Server listens for incoming DTLS connections
-func Server(conn NetConnWithCid, config *Config) (*Conn, error) {
-	if config == nil || config.Certificate == nil {
-		return nil, errServerMustHaveCertificate
+// Client establishes a DTLS connection over an existing conn, every Read
+// of conn must return a single datagram
+func Client(conn net.Conn, config *Config) (*Conn, error) {
+	return createConn(withCid(conn), clientFlightHandler, clientHandshakeHandler, config, true)
+}
+
+// Server listens for incoming DTLS connections. If conn implements
+// NetConnWithCid, it is promoted once a connection ID is negotiated.
+func Server(conn net.Conn, config *Config) (*Conn, error) {
+	if config == nil || (config.Certificate == nil && config.PSK == nil) {
+		return nil, errServerMustHaveCertificateOrPSK
 	}
-	return createConn(conn, serverFlightHandler, serverHandshakeHandler, config, false)
+	return createConn(withCid(conn), serverFlightHandler, serverHandshakeHandler, config, false)
 }
 
-// Read reads data from the connection.
+// Read reads the next record from the connection. If the record doesn't
+// fit in p, Read fails and keeps the record, unless PartialReads is set.
+// It returns io.EOF once the peer sent close_notify and all the data
+// received before it has been read.
 func (c *Conn) Read(p []byte) (n int, err error) {
-	out, ok := <-c.decrypted
-	if !ok {
-		return 0, c.getConnErr()
+	c.readLock.Lock()
+	defer c.readLock.Unlock()
+
+	if len(c.pendingRead) == 0 {
+		if c.pendingRead, err = c.nextRecord(); err != nil {
+			return 0, err
+		}
 	}
-	if len(p) < len(out) {
+
+	if len(p) < len(c.pendingRead) && !c.partialReads {
 		return 0, errBufferTooSmall
 	}
+	n = copy(p, c.pendingRead)
+	c.pendingRead = c.pendingRead[n:]
+	return n, nil
+}
+
+// ReadMessage returns the next record received on the connection, or the
+// rest of it if Read returned only part of it
+func (c *Conn) ReadMessage() ([]byte, error) {
+	c.readLock.Lock()
+	defer c.readLock.Unlock()
+
+	if len(c.pendingRead) != 0 {
+		out := c.pendingRead
+		c.pendingRead = nil
+		return out, nil
+	}
+	return c.nextRecord()
+}
+
+// nextRecord pops the receive queue, the caller must hold the readLock
+func (c *Conn) nextRecord() ([]byte, error) {
+	select {
+	case out, ok := <-c.decrypted:
+		if !ok {
+			return nil, c.getConnErr()
+		}
+		return out, nil
+
+	case <-c.remoteClosed:
+		// Records received before the close_notify are still delivered
+		select {
+		case out, ok := <-c.decrypted:
+			if ok {
+				return out, nil
+			}
+		default:
+		}
+		return nil, io.EOF
 
-	copy(p, out)
-	return len(out), nil
+	case <-c.readDeadline.Done():
+		return nil, deadline.ErrTimeout
+	}
+}
+
+// enqueueRecord pushes received application data to the receive queue,
+// following the overflow policy if it is full
+func (c *Conn) enqueueRecord(data []byte) {
+	for {
+		select {
+		case c.decrypted <- data:
+			return
+		default:
+		}
+
+		switch c.receiveQueueOverflow {
+		case QueueOverflowDropOldest:
+			select {
+			case <-c.decrypted:
+			default:
+			}
+		case QueueOverflowBlock:
+			select {
+			case c.decrypted <- data:
+			case <-c.closed:
+			}
+			return
+		default:
+			return
+		}
+	}
 }
 
-// Write writes len(p) bytes from p to the DTLS connection
+// Write writes len(p) bytes from p to the DTLS connection, split into
+// records of at most 16KB
 func (c *Conn) Write(p []byte) (int, error) {
+	n := 0
+	for {
+		chunk := p
+		if len(chunk) > maxPlaintextLength {
+			chunk = chunk[:maxPlaintextLength]
+		}
+		if err := c.writeRecord(chunk); err != nil {
+			return n, err
+		}
+		n += len(chunk)
+		if p = p[len(chunk):]; len(p) == 0 {
+			return n, nil
+		}
+	}
+}
+
+// WriteMessage sends p as a single record, the peer's ReadMessage returns
+// it whole
+func (c *Conn) WriteMessage(p []byte) error {
+	if len(p) > maxPlaintextLength {
+		return errMessageTooLong
+	}
+	return c.writeRecord(p)
+}
+
+func (c *Conn) writeRecord(p []byte) error {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
 	if c.localEpoch == 0 {
-		return 0, errHandshakeInProgress
+		return errHandshakeInProgress
 	} else if c.getConnErr() != nil {
-		return 0, c.getConnErr()
+		return c.getConnErr()
+	} else if c.writeClosed {
+		return errWriteClosed
+	} else if c.writeDeadline.Exceeded() {
+		return deadline.ErrTimeout
 	}
 
 	rl := &recordLayer{
@@ -211,12 +494,15 @@ func (c *Conn) Write(p []byte) (int, error) {
 
 	c.localSequenceNumber++
 
-	return len(p), nil
+	return nil
 }
 
-// Close closes the connection.
+// Close sends close_notify, unless CloseWrite already did, and closes the
+// connection
 func (c *Conn) Close() error {
-	c.notify(alertLevelFatal, alertCloseNotify)
+	if c.getConnErr() == nil {
+		c.closeNotify()
+	}
 	c.stopWithError(ErrConnClosed)
 	if err := c.getConnErr(); err != ErrConnClosed {
 		return err
@@ -224,6 +510,28 @@ func (c *Conn) Close() error {
 	return nil
 }
 
+// CloseWrite sends close_notify and shuts down the writing side of the
+// connection. The peer may keep sending, Read returns io.EOF once it is
+// done too. https://tools.ietf.org/html/rfc5246#section-7.2.1
+func (c *Conn) CloseWrite() error {
+	if err := c.getConnErr(); err != nil {
+		return err
+	}
+	c.closeNotify()
+	return nil
+}
+
+// closeNotify sends close_notify at warning level once
+func (c *Conn) closeNotify() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !c.writeClosed {
+		c.writeClosed = true
+		c.sendAlert(AlertLevelWarning, AlertCloseNotify)
+	}
+}
+
 // RemoteCertificate exposes the remote certificate
 func (c *Conn) RemoteCertificate() *x509.Certificate {
 	c.lock.RLock()
@@ -231,17 +539,35 @@ func (c *Conn) RemoteCertificate() *x509.Certificate {
 	return c.remoteCertificate
 }
 
+// ExtendedMasterSecret reports whether the Extended Master Secret
+// (RFC 7627) was negotiated for this connection
+func (c *Conn) ExtendedMasterSecret() bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.extendedMasterSecret
+}
+
+// DidResume reports whether this connection resumed a previous session
+// with an abbreviated handshake
+func (c *Conn) DidResume() bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.resumed
+}
+
 // ExportKeyingMaterial from https://tools.ietf.org/html/rfc5705
 // This allows protocols to use DTLS for key establishment, but
-// then use some of the keying material for their own purposes
+// then use some of the keying material for their own purposes.
+// If context is nil no context is used, an empty non-nil context is
+// mixed in with its length of zero.
 func (c *Conn) ExportKeyingMaterial(label []byte, context []byte, length int) ([]byte, error) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
 	if c.localEpoch == 0 {
 		return nil, errHandshakeInProgress
-	} else if len(context) != 0 {
-		return nil, errContextUnsupported
+	} else if len(context) > 0xffff {
+		return nil, errContextTooLong
 	} else if _, ok := invalidKeyingLabels[string(label)]; ok {
 		return nil, errReservedExportKeyingMaterial
 	}
@@ -261,6 +587,10 @@ func (c *Conn) ExportKeyingMaterial(label []byte, context []byte, length int) ([
 	} else {
 		seed = append(append(seed, remoteRandom...), localRandom...)
 	}
+	if context != nil {
+		seed = append(seed, byte(len(context)>>8), byte(len(context)))
+		seed = append(seed, context...)
+	}
 	return prfPHash(c.masterSecret, seed, length, c.cipherSuite.hashFunc())
 }
 
@@ -274,6 +604,7 @@ func (c *Conn) internalSend(pkt *recordLayer, shouldEncrypt bool) {
 	if h, ok := pkt.content.(*handshake); ok {
 		c.handshakeCache.push(raw[recordLayerHeaderSize:], pkt.recordLayerHeader.epoch,
 			h.handshakeHeader.messageSequence /* isLocal */, true, c.currFlight.get())
+		c.traceHandshakeMessage(true, &pkt.recordLayerHeader, &h.handshakeHeader)
 	}
 
 	if shouldEncrypt {
@@ -286,16 +617,21 @@ func (c *Conn) internalSend(pkt *recordLayer, shouldEncrypt bool) {
 
 	if _, err := c.nextConn.Write(raw); err != nil {
 		c.stopWithError(err)
+		return
 	}
+	atomic.AddUint64(&c.stats.recordsSent, 1)
+	atomic.AddUint64(&c.stats.bytesSent, uint64(len(raw)))
 }
 
 func (c *Conn) handleIncoming(buf []byte) error {
+	atomic.AddUint64(&c.stats.bytesReceived, uint64(len(buf)))
 	pkts, err := unpackDatagram(buf)
 	if err != nil {
 		return err
 	}
 
 	for _, p := range pkts {
+		atomic.AddUint64(&c.stats.recordsReceived, 1)
 		err := c.handleIncomingPacket(p)
 		if err != nil {
 			return err
@@ -318,22 +654,49 @@ func (c *Conn) handleIncomingPacket(buf []byte) error {
 		return err
 	}
 	if h.epoch < c.remoteEpoch {
-		fmt.Println("handleIncoming: old epoch, dropping packet")
+		c.log.Debugf("discarded record from old epoch %d", h.epoch)
+		c.traceRecordDropped(h, DropOldEpoch)
 		return nil
 	}
 
 	if c.remoteEpoch != 0 {
 		if c.cipherSuite == nil {
-			fmt.Println("handleIncoming: Handshake not finished, dropping packet")
+			c.log.Debug("discarded encrypted record, handshake not finished")
+			c.traceRecordDropped(h, DropHandshakeNotDone)
 			return nil
 		}
 
 		var err error
 		buf, err = c.cipherSuite.decrypt(buf)
 		if err != nil {
-			fmt.Println(err)
+			atomic.AddUint64(&c.stats.decryptFailures, 1)
+			c.log.Debugf("discarded record that failed to decrypt: %v", err)
+			c.traceRecordDropped(h, DropDecryptFailed)
 			return nil
 		}
+
+		// Only authenticated records move the window
+		// https://tools.ietf.org/html/rfc6347#section-4.1.2.6
+		if c.replayDetector != nil {
+			switch tooOld, duplicate := c.replayDetector.check(h.epoch, h.sequenceNumber); {
+			case tooOld:
+				atomic.AddUint64(&c.replayStats.TooOld, 1)
+				c.traceRecordDropped(h, DropReplayTooOld)
+				return nil
+			case duplicate:
+				atomic.AddUint64(&c.replayStats.Duplicate, 1)
+				c.traceRecordDropped(h, DropReplayDuplicate)
+				return nil
+			}
+			c.replayDetector.accept(h.epoch, h.sequenceNumber)
+		}
+	}
+
+	if c.tracer != nil && h.contentType == contentTypeHandshake {
+		hh := &handshakeHeader{}
+		if err := hh.Unmarshal(buf[recordLayerHeaderSize:]); err == nil {
+			c.traceHandshakeMessage(false, h, hh)
+		}
 	}
 
 	pushSuccess, err := c.fragmentBuffer.push(buf)
@@ -349,7 +712,7 @@ func (c *Conn) handleIncomingPacket(buf []byte) error {
 		return err
 	}
 
-	err = c.handleRecordContent(r.content)
+	err = c.handleRecordContent(h, r.content)
 	if err != nil {
 		return err
 	}
@@ -357,20 +720,42 @@ func (c *Conn) handleIncomingPacket(buf []byte) error {
 	return nil
 }
 
-func (c *Conn) handleRecordContent(rcontent content) error {
+func (c *Conn) handleRecordContent(h *recordLayerHeader, rcontent content) error {
 	switch content := rcontent.(type) {
 	case *alert:
-		if content.alertDescription == alertCloseNotify {
-			return c.Close()
+		if content.alertDescription == AlertCloseNotify {
+			select {
+			case <-c.handshakeCompleted:
+			default:
+				// The peer gave up on the handshake
+				return &AlertError{Level: content.alertLevel, Description: content.alertDescription}
+			}
+
+			// Read returns io.EOF from now on, our own close_notify is sent
+			// by Close. We keep reading so that the transport is drained.
+			c.remoteCloseOnce.Do(func() {
+				close(c.remoteClosed)
+			})
+			return nil
+		} else if content.alertLevel == AlertLevelWarning {
+			// Only fatal alerts end the connection
+			c.log.Debugf("ignored warning alert %s", content.alertDescription)
+			return nil
 		}
-		return fmt.Errorf("alert: %v", content)
+		return &AlertError{Level: content.alertLevel, Description: content.alertDescription}
 	case *changeCipherSpec:
 		c.remoteEpoch++
 	case *applicationData:
-		c.decrypted <- content.data
+		select {
+		case <-c.remoteClosed:
+			// Nothing is accepted after close_notify
+			c.traceRecordDropped(h, DropAfterCloseNotify)
+		default:
+			c.enqueueRecord(content.data)
+		}
 	case *tls12cid:
 		// recurse into the inner content
-		return c.handleRecordContent(rcontent.(*tls12cid).innerContent)
+		return c.handleRecordContent(h, rcontent.(*tls12cid).innerContent)
 	default:
 		return fmt.Errorf("Unhandled contentType %d", content.contentType())
 	}
@@ -378,10 +763,14 @@ func (c *Conn) handleRecordContent(rcontent content) error {
 	return nil
 }
 
-func (c *Conn) notify(level alertLevel, desc alertDescription) {
+func (c *Conn) notify(level AlertLevel, desc AlertDescription) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
+	c.sendAlert(level, desc)
+}
 
+// sendAlert sends an alert, the caller must hold the lock
+func (c *Conn) sendAlert(level AlertLevel, desc AlertDescription) {
 	c.internalSend(&recordLayer{
 		recordLayerHeader: recordLayerHeader{
 			epoch:           c.localEpoch,
@@ -392,21 +781,33 @@ func (c *Conn) notify(level alertLevel, desc alertDescription) {
 			alertLevel:       level,
 			alertDescription: desc,
 		},
-	}, true)
+	}, c.localEpoch != 0)
 
 	c.localSequenceNumber++
 }
 
 func (c *Conn) signalHandshakeComplete() {
-	select {
-	case <-c.handshakeCompleted:
-	default:
+	c.handshakeCompletedOnce.Do(func() {
 		close(c.handshakeCompleted)
-	}
+	})
 }
 
 func (c *Conn) startHandshakeOutbound() {
 	go func() {
+		var lastSent flightVal
+
+		// sendFlight sends the current flight, a flight sent twice in a
+		// row is a retransmission. The server sends nothing at Flight 0.
+		sendFlight := func() (bool, error) {
+			flight := c.currFlight.get()
+			if flight == lastSent {
+				atomic.AddUint64(&c.stats.retransmissions[flight-1], 1)
+			} else if flight != flight0 {
+				lastSent = flight
+			}
+			return c.flightHandler(c)
+		}
+
 		for {
 			var (
 				isFinished bool
@@ -416,14 +817,15 @@ func (c *Conn) startHandshakeOutbound() {
 			case <-c.handshakeCompleted:
 				return
 			case <-c.workerTicker.C:
-				isFinished, err = c.flightHandler(c)
+				c.traceTimerFired()
+				isFinished, err = sendFlight()
 			case <-c.currFlight.workerTrigger:
-				isFinished, err = c.flightHandler(c)
+				isFinished, err = sendFlight()
 			}
 
 			switch {
 			case err != nil:
-				c.stopWithError(err)
+				c.abort(err)
 				return
 			case c.getConnErr() != nil:
 				return
@@ -434,19 +836,34 @@ func (c *Conn) startHandshakeOutbound() {
 	}()
 }
 
+// abort tells the peer why err ends the connection with a fatal alert and
+// stops the connection. Alerts received from the peer are not answered.
+// https://tools.ietf.org/html/rfc5246#section-7.2.2
+func (c *Conn) abort(err error) {
+	if _, ok := err.(*AlertError); !ok {
+		c.notify(AlertLevelFatal, alertForError(err))
+	}
+	c.log.Warnf("connection aborted: %v", err)
+	c.stopWithError(err)
+}
+
+// stopWithError stops the connection, only the first error is kept
 func (c *Conn) stopWithError(err error) {
-	if connErr := c.nextConn.Close(); connErr != nil {
-		if err != ErrConnClosed {
-			connErr = fmt.Errorf("%v\n%v", err, connErr)
+	c.stopOnce.Do(func() {
+		if connErr := c.nextConn.Close(); connErr != nil {
+			if err != ErrConnClosed {
+				connErr = fmt.Errorf("%v\n%v", err, connErr)
+			}
+			err = connErr
 		}
-		err = connErr
-	}
 
-	c.connErr.Store(struct{ error }{err})
+		c.connErr.Store(struct{ error }{err})
 
-	c.workerTicker.Stop()
+		c.workerTicker.Stop()
 
-	c.signalHandshakeComplete()
+		close(c.closed)
+		c.signalHandshakeComplete()
+	})
 }
 
 func (c *Conn) getConnErr() error {
@@ -454,10 +871,29 @@ func (c *Conn) getConnErr() error {
 	return err.error
 }
 
+// ReplayStats returns the number of records discarded by the anti-replay
+// window so far
+func (c *Conn) ReplayStats() ReplayStats {
+	return ReplayStats{
+		TooOld:    atomic.LoadUint64(&c.replayStats.TooOld),
+		Duplicate: atomic.LoadUint64(&c.replayStats.Duplicate),
+	}
+}
+
 func (c *Conn) PromoteToCidConnection(cid []byte) error {
+	c.logCid.Store(cid)
 	return c.nextConn.PromoteToCidConnection(cid)
 }
 
+// logPrefix scopes log messages to the remote address and, once
+// negotiated, the server's CID
+func (c *Conn) logPrefix() string {
+	if cid, ok := c.logCid.Load().([]byte); ok && len(cid) != 0 {
+		return fmt.Sprintf("%s cid %x: ", c.nextConn.RemoteAddr(), cid)
+	}
+	return fmt.Sprintf("%s: ", c.nextConn.RemoteAddr())
+}
+
 // LocalAddr is a stub
 func (c *Conn) LocalAddr() net.Addr {
 	return c.nextConn.LocalAddr()
@@ -468,19 +904,28 @@ func (c *Conn) RemoteAddr() net.Addr {
 	return c.nextConn.RemoteAddr()
 }
 
-// SetDeadline is a stub
+// SetDeadline sets the read and write deadlines. Deadlines only apply to
+// Read and Write, they are not passed to the underlying conn, whose reader
+// keeps running the handshake and the alerts while a Read times out.
 func (c *Conn) SetDeadline(t time.Time) error {
-	return c.nextConn.SetDeadline(t)
+	c.readDeadline.Set(t)
+	c.writeDeadline.Set(t)
+	return nil
 }
 
-// SetReadDeadline is a stub
+// SetReadDeadline sets the time after which Read and ReadMessage fail with
+// an error whose Timeout method returns true. The connection stays usable,
+// a deadline in the future or the zero time lets them read again.
 func (c *Conn) SetReadDeadline(t time.Time) error {
-	return c.nextConn.SetReadDeadline(t)
+	c.readDeadline.Set(t)
+	return nil
 }
 
-// SetWriteDeadline is a stub
+// SetWriteDeadline sets the time after which Write and WriteMessage fail
+// with an error whose Timeout method returns true
 func (c *Conn) SetWriteDeadline(t time.Time) error {
-	return c.nextConn.SetWriteDeadline(t)
+	c.writeDeadline.Set(t)
+	return nil
 }
 
 func (c *Conn) getCidForSending() []byte {
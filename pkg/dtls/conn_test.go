@@ -1,10 +1,403 @@
 package dtls
 
-/*
-	FIXME(tho) - when dtls.Conn is created from Pipe objects (net.Conn) the
-	usual UDP adaptors won't work.  we need to add ad hoc NetConnWithCid
-	adapters.
-*/
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/thomas-fossati/dtls/pkg/dtls/internal/deadline"
+)
+
+func TestHandshakeTimeout(t *testing.T) {
+	ca, cb := net.Pipe()
+	go func() {
+		_, _ = io.Copy(ioutil.Discard, cb)
+	}()
+
+	// Nobody answers the ClientHello
+	_, err := Client(ca, &Config{HandshakeTimeout: 100 * time.Millisecond})
+	if err != errHandshakeTimeout {
+		t.Errorf("Client: got %v, want %v", err, errHandshakeTimeout)
+	}
+	_ = cb.Close()
+}
+
+// handshakePipe connects a client and a server over an in-memory pipe
+func handshakePipe(t *testing.T) (*Conn, *Conn) {
+	ca, cb := net.Pipe()
+	return handshakeConns(t, ca, cb)
+}
+
+// handshakeConns runs a client on ca and a server on cb
+func handshakeConns(t *testing.T, ca, cb net.Conn) (*Conn, *Conn) {
+	cert, key, err := GenerateSelfSigned()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		c   *Conn
+		err error
+	}
+	res := make(chan result)
+	go func() {
+		client, err := Client(ca, &Config{})
+		res <- result{client, err}
+	}()
+
+	server, err := Server(cb, &Config{Certificate: cert, PrivateKey: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := <-res
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+	return r.c, server
+}
+
+// queueConn returns a Conn that only has its receive queue set up
+func queueConn(size int, policy QueueOverflowPolicy) *Conn {
+	return &Conn{
+		decrypted:            make(chan []byte, size),
+		closed:               make(chan struct{}),
+		remoteClosed:         make(chan struct{}),
+		readDeadline:         deadline.New(),
+		receiveQueueOverflow: policy,
+	}
+}
+
+func TestReceiveQueueOverflow(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		policy QueueOverflowPolicy
+		want   []string
+	}{
+		{"DropNewest", QueueOverflowDropNewest, []string{"a", "b"}},
+		{"DropOldest", QueueOverflowDropOldest, []string{"b", "c"}},
+	} {
+		c := queueConn(2, test.policy)
+		for _, msg := range []string{"a", "b", "c"} {
+			c.enqueueRecord([]byte(msg))
+		}
+
+		got := []string{}
+		for len(c.decrypted) > 0 {
+			msg, err := c.ReadMessage()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, string(msg))
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %#v, want %#v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestReceiveQueueOverflowBlock(t *testing.T) {
+	c := queueConn(1, QueueOverflowBlock)
+	c.enqueueRecord([]byte("a"))
+
+	done := make(chan struct{})
+	go func() {
+		c.enqueueRecord([]byte("b"))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("enqueueRecord: did not wait for the full queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	for _, want := range []string{"a", "b"} {
+		msg, err := c.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		} else if string(msg) != want {
+			t.Errorf("ReadMessage: got %q, want %q", msg, want)
+		}
+	}
+	<-done
+
+	// Closing the Conn releases a waiting enqueueRecord
+	c.enqueueRecord([]byte("c"))
+	done = make(chan struct{})
+	go func() {
+		c.enqueueRecord([]byte("d"))
+		close(done)
+	}()
+	close(c.closed)
+	<-done
+}
+
+func TestPartialReads(t *testing.T) {
+	c := queueConn(1, QueueOverflowDropNewest)
+	c.enqueueRecord([]byte("hello"))
+
+	// The record is kept for a large enough buffer
+	buf := make([]byte, 2)
+	if _, err := c.Read(buf); err != errBufferTooSmall {
+		t.Errorf("Read: got %v, want %v", err, errBufferTooSmall)
+	}
+
+	c.partialReads = true
+	got := []string{}
+	for i := 0; i < 2; i++ {
+		n, err := c.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, string(buf[:n]))
+	}
+	rest, err := c.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, string(rest))
+
+	want := []string{"he", "ll", "o"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("partial Reads: got %#v, want %#v", got, want)
+	}
+}
+
+func TestWriteMessageTooLong(t *testing.T) {
+	c := &Conn{}
+	if err := c.WriteMessage(make([]byte, maxPlaintextLength+1)); err != errMessageTooLong {
+		t.Errorf("WriteMessage: got %v, want %v", err, errMessageTooLong)
+	}
+}
+
+func TestMessageBoundaries(t *testing.T) {
+	client, server := handshakePipe(t)
+	defer func() {
+		_ = client.Close()
+		_ = server.Close()
+	}()
+
+	for _, msg := range []string{"a", "bc"} {
+		if err := client.WriteMessage([]byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, want := range []string{"a", "bc"} {
+		msg, err := server.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		} else if string(msg) != want {
+			t.Errorf("ReadMessage: got %q, want %q", msg, want)
+		}
+	}
+
+	// Write splits p into records of at most 16KB
+	p := bytes.Repeat([]byte{0x42}, 2*maxPlaintextLength+10)
+	if n, err := client.Write(p); err != nil {
+		t.Fatal(err)
+	} else if n != len(p) {
+		t.Errorf("Write: got %d, want %d", n, len(p))
+	}
+	got := []byte{}
+	sizes := []int{}
+	for len(got) < len(p) {
+		msg, err := server.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, msg...)
+		sizes = append(sizes, len(msg))
+	}
+	if want := []int{maxPlaintextLength, maxPlaintextLength, 10}; !reflect.DeepEqual(sizes, want) {
+		t.Errorf("record sizes: got %#v, want %#v", sizes, want)
+	}
+	if !bytes.Equal(got, p) {
+		t.Error("ReadMessage: got different data than written")
+	}
+}
+
+// lastWriteConn keeps a copy of the last datagram written
+type lastWriteConn struct {
+	net.Conn
+	lock sync.Mutex
+	last []byte
+}
+
+func (c *lastWriteConn) Write(p []byte) (int, error) {
+	c.lock.Lock()
+	c.last = append([]byte{}, p...)
+	c.lock.Unlock()
+	return c.Conn.Write(p)
+}
+
+func TestCloseNotifyWarning(t *testing.T) {
+	ca, cb := net.Pipe()
+	tap := &lastWriteConn{Conn: ca}
+	client, server := handshakeConns(t, tap, cb)
+	defer func() {
+		_ = client.Close()
+		_ = server.Close()
+	}()
+
+	if err := client.CloseWrite(); err != nil {
+		t.Fatal(err)
+	}
+
+	tap.lock.Lock()
+	raw := tap.last
+	tap.lock.Unlock()
+	raw, err := server.cipherSuite.decrypt(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &recordLayer{}
+	if err := r.Unmarshal(raw); err != nil {
+		t.Fatal(err)
+	}
+	want := &alert{alertLevel: AlertLevelWarning, alertDescription: AlertCloseNotify}
+	if !reflect.DeepEqual(r.content, want) {
+		t.Errorf("CloseWrite: got %#v, want %#v", r.content, want)
+	}
+}
+
+func TestCloseWrite(t *testing.T) {
+	client, server := handshakePipe(t)
+	defer func() {
+		_ = client.Close()
+		_ = server.Close()
+	}()
+
+	// The pipe doesn't buffer, the server reads while the client writes
+	written := make(chan error, 1)
+	go func() {
+		for _, msg := range []string{"a", "b"} {
+			if _, err := client.Write([]byte(msg)); err != nil {
+				written <- err
+				return
+			}
+		}
+		written <- client.CloseWrite()
+	}()
+
+	// The data sent before close_notify is read before io.EOF
+	buf := make([]byte, 16)
+	for _, want := range []string{"a", "b"} {
+		n, err := server.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		} else if string(buf[:n]) != want {
+			t.Errorf("Read: got %q, want %q", buf[:n], want)
+		}
+	}
+	if _, err := server.Read(buf); err != io.EOF {
+		t.Errorf("Read after close_notify: got %v, want %v", err, io.EOF)
+	}
+	if err := <-written; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Write([]byte("c")); err != errWriteClosed {
+		t.Errorf("Write after CloseWrite: got %v, want %v", err, errWriteClosed)
+	}
+
+	// The server can still send to the client
+	sent := make(chan error, 1)
+	go func() {
+		_, err := server.Write([]byte("d"))
+		sent <- err
+	}()
+	n, err := client.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	} else if string(buf[:n]) != "d" {
+		t.Errorf("Read after CloseWrite: got %q, want %q", buf[:n], "d")
+	}
+	if err := <-sent; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// dropTracer records the RecordDropped events
+type dropTracer struct {
+	drops []RecordDroppedEvent
+}
+
+func (t *dropTracer) HandshakeMessage(c *Conn, e HandshakeMessageEvent) {}
+func (t *dropTracer) FlightChanged(c *Conn, e FlightEvent)              {}
+func (t *dropTracer) TimerFired(c *Conn, e TimerEvent)                  {}
+func (t *dropTracer) RecordDropped(c *Conn, e RecordDroppedEvent) {
+	t.drops = append(t.drops, e)
+}
+
+func TestAppDataAfterCloseNotify(t *testing.T) {
+	tracer := &dropTracer{}
+	c := queueConn(1, QueueOverflowDropNewest)
+	c.tracer = tracer
+	close(c.remoteClosed)
+
+	h := &recordLayerHeader{contentType: contentTypeApplicationData, epoch: 1, sequenceNumber: 3}
+	if err := c.handleRecordContent(h, &applicationData{data: []byte("late")}); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.decrypted) != 0 {
+		t.Errorf("queue after close_notify: got %d records, want 0", len(c.decrypted))
+	}
+	want := []RecordDroppedEvent{{ContentType: uint8(contentTypeApplicationData), Epoch: 1, SequenceNumber: 3, Reason: DropAfterCloseNotify}}
+	if !reflect.DeepEqual(tracer.drops, want) {
+		t.Errorf("RecordDropped: got %#v, want %#v", tracer.drops, want)
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	client, server := handshakePipe(t)
+	defer func() {
+		_ = client.Close()
+		_ = server.Close()
+	}()
+
+	buf := make([]byte, 16)
+	if err := server.SetReadDeadline(time.Now().Add(50 * time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	_, err := server.Read(buf)
+	if nerr, ok := err.(net.Error); !ok || !nerr.Timeout() {
+		t.Fatalf("Read past deadline: got %v, want a timeout", err)
+	}
+
+	if err := client.SetWriteDeadline(time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Write([]byte("a"))
+	if nerr, ok := err.(net.Error); !ok || !nerr.Timeout() {
+		t.Fatalf("Write past deadline: got %v, want a timeout", err)
+	}
+
+	// The Conn survives the timeouts once the deadlines are reset
+	if err := server.SetReadDeadline(time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SetWriteDeadline(time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	sent := make(chan error, 1)
+	go func() {
+		_, err := client.Write([]byte("b"))
+		sent <- err
+	}()
+	n, err := server.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	} else if string(buf[:n]) != "b" {
+		t.Errorf("Read after reset: got %q, want %q", buf[:n], "b")
+	}
+	if err := <-sent; err != nil {
+		t.Fatal(err)
+	}
+}
 
 /*
 import (
@@ -113,7 +506,7 @@ func testClient(c net.Conn) (*Conn, error) {
 		return nil, err
 	}
 
-	client, err := Client(ClientUDPConnWithCid{udpConn: c}, &Config{clientCert, clientKey})
+	client, err := Client(c, &Config{clientCert, clientKey})
 	if err != nil {
 		return nil, err
 	}
@@ -154,9 +547,9 @@ func TestExportKeyingMaterial(t *testing.T) {
 	}
 
 	c.localEpoch = 1
-	_, err = c.ExportKeyingMaterial(exportLabel, []byte{0x00}, 0)
-	if err != errContextUnsupported {
-		t.Errorf("ExportKeyingMaterial with context: expected '%s' actual '%s'", errContextUnsupported, err)
+	_, err = c.ExportKeyingMaterial(exportLabel, make([]byte, 0x10000), 0)
+	if err != errContextTooLong {
+		t.Errorf("ExportKeyingMaterial with context: expected '%s' actual '%s'", errContextTooLong, err)
 	}
 
 	for k := range invalidKeyingLabels {
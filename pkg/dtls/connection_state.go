@@ -0,0 +1,67 @@
+package dtls
+
+import (
+	"crypto/x509"
+	"time"
+)
+
+// ConnectionState describes what was negotiated on a Conn
+type ConnectionState struct {
+	CipherSuite CipherSuiteID
+
+	// NamedCurve is the curve of the ECDHE key exchange, zero if the
+	// cipher suite doesn't use one or the session was resumed
+	NamedCurve NamedCurve
+
+	// PeerCertificates holds the peer's leaf certificate, the rest of the
+	// chain isn't parsed. It is empty if the peer didn't send one.
+	PeerCertificates []*x509.Certificate
+
+	// LocalCID is the connection ID the peer puts in the records it sends
+	// us, RemoteCID the one we put in the records we send. They are nil if
+	// no connection ID was negotiated in that direction.
+	LocalCID, RemoteCID []byte
+
+	// SRTPProtectionProfile is the profile agreed on with use_srtp, zero if
+	// the extension wasn't negotiated
+	SRTPProtectionProfile SRTPProtectionProfile
+
+	ExtendedMasterSecret bool
+	DidResume            bool
+
+	// HandshakeDuration is the time from the start of the handshake until
+	// the Conn was returned to the caller
+	HandshakeDuration time.Duration
+}
+
+// ConnectionState returns the parameters negotiated on the connection
+func (c *Conn) ConnectionState() ConnectionState {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	state := ConnectionState{
+		LocalCID:              cloneBytes(c.getCidForReceiving()),
+		RemoteCID:             cloneBytes(c.getCidForSending()),
+		SRTPProtectionProfile: c.srtpProtectionProfile,
+		ExtendedMasterSecret:  c.extendedMasterSecret,
+		DidResume:             c.resumed,
+		HandshakeDuration:     c.handshakeDuration,
+	}
+	if c.cipherSuite != nil {
+		state.CipherSuite = c.cipherSuite.ID()
+	}
+	if c.localKeypair != nil {
+		state.NamedCurve = c.localKeypair.curve
+	}
+	if c.remoteCertificate != nil {
+		state.PeerCertificates = []*x509.Certificate{c.remoteCertificate}
+	}
+	return state
+}
+
+func cloneBytes(b []byte) []byte {
+	if len(b) == 0 {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
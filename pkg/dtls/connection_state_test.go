@@ -0,0 +1,51 @@
+package dtls
+
+import (
+	"crypto/x509"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConnectionState(t *testing.T) {
+	remoteCertificate := &x509.Certificate{Raw: []byte{0x30, 0x00}}
+	c := &Conn{
+		isClient:              true,
+		cipherSuite:           &cipherSuiteTLSEcdheEcdsaWithAes128GcmSha256{},
+		localKeypair:          &namedCurveKeypair{curve: NamedCurveP256},
+		remoteCertificate:     remoteCertificate,
+		ccid:                  []byte{0x01, 0x02},
+		scid:                  []byte{0x03},
+		srtpProtectionProfile: SRTP_AES128_CM_HMAC_SHA1_80,
+		extendedMasterSecret:  true,
+		handshakeDuration:     time.Second,
+	}
+
+	expected := ConnectionState{
+		CipherSuite:           TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+		NamedCurve:            NamedCurveP256,
+		PeerCertificates:      []*x509.Certificate{remoteCertificate},
+		LocalCID:              []byte{0x01, 0x02},
+		RemoteCID:             []byte{0x03},
+		SRTPProtectionProfile: SRTP_AES128_CM_HMAC_SHA1_80,
+		ExtendedMasterSecret:  true,
+		HandshakeDuration:     time.Second,
+	}
+	if state := c.ConnectionState(); !reflect.DeepEqual(state, expected) {
+		t.Errorf("ConnectionState client: got %#v, want %#v", state, expected)
+	}
+
+	// The server receives on its own CID
+	c.isClient = false
+	expected.LocalCID, expected.RemoteCID = []byte{0x03}, []byte{0x01, 0x02}
+	if state := c.ConnectionState(); !reflect.DeepEqual(state, expected) {
+		t.Errorf("ConnectionState server: got %#v, want %#v", state, expected)
+	}
+
+	// Resumed sessions, and PSK without ECDHE, have no curve or certificate
+	c = &Conn{cipherSuite: &cipherSuiteTLSPskWithAes128GcmSha256{}, resumed: true}
+	expected = ConnectionState{CipherSuite: TLS_PSK_WITH_AES_128_GCM_SHA256, DidResume: true}
+	if state := c.ConnectionState(); !reflect.DeepEqual(state, expected) {
+		t.Errorf("ConnectionState PSK: got %#v, want %#v", state, expected)
+	}
+}
@@ -0,0 +1,140 @@
+package dtls
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"net"
+	"time"
+)
+
+const (
+	cookieKeyLength       = 32
+	defaultCookieRotation = time.Minute
+)
+
+// CookieGenerator makes the cookie a server sends in a HelloVerifyRequest.
+// https://tools.ietf.org/html/rfc6347#section-4.2.1
+type CookieGenerator interface {
+	// GenerateCookie returns the cookie for a ClientHello received from
+	// raddr. params holds the ClientHello fields the client must repeat
+	// along with the cookie, it should be covered by the cookie.
+	GenerateCookie(raddr net.Addr, params []byte) ([]byte, error)
+}
+
+// CookieVerifier checks the cookie a client sends back in its second
+// ClientHello, no state is kept for the client before that
+type CookieVerifier interface {
+	// VerifyCookie reports whether cookie was generated for a ClientHello
+	// received from raddr with the same params
+	VerifyCookie(raddr net.Addr, params, cookie []byte) bool
+}
+
+// CookieHMAC is a CookieGenerator and CookieVerifier that uses an HMAC
+// over the address of the client and the ClientHello parameters. The HMAC
+// secret is derived from a key and the current rotation period, so servers
+// that share the key verify each other's cookies without exchanging any
+// state. Cookies are accepted for a grace window around the period they
+// were generated in, which also covers clock skew between servers.
+type CookieHMAC struct {
+	key      []byte
+	rotation time.Duration
+	grace    time.Duration
+	now      func() time.Time
+}
+
+// NewCookieHMAC returns a CookieHMAC that rotates its secret every
+// rotation and accepts cookies up to grace after their period ended. If
+// key is nil a random one is used, only the server that created the
+// CookieHMAC then verifies its cookies. If rotation is not positive a
+// default of one minute is used.
+func NewCookieHMAC(key []byte, rotation, grace time.Duration) (*CookieHMAC, error) {
+	if key == nil {
+		key = make([]byte, cookieKeyLength)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+	if rotation <= 0 {
+		rotation = defaultCookieRotation
+	}
+	if grace < 0 {
+		grace = 0
+	}
+
+	return &CookieHMAC{
+		key:      append([]byte{}, key...),
+		rotation: rotation,
+		grace:    grace,
+		now:      time.Now,
+	}, nil
+}
+
+// GenerateCookie implements CookieGenerator
+func (c *CookieHMAC) GenerateCookie(raddr net.Addr, params []byte) ([]byte, error) {
+	return c.mac(c.period(c.now()), raddr, params), nil
+}
+
+// VerifyCookie implements CookieVerifier
+func (c *CookieHMAC) VerifyCookie(raddr net.Addr, params, cookie []byte) bool {
+	if len(cookie) == 0 {
+		return false
+	}
+
+	now := c.now()
+	for p := c.period(now.Add(-c.grace)); p <= c.period(now.Add(c.grace)); p++ {
+		if hmac.Equal(cookie, c.mac(p, raddr, params)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CookieHMAC) period(t time.Time) int64 {
+	return t.UnixNano() / int64(c.rotation)
+}
+
+// mac derives the secret of a rotation period from the key and uses it
+// to authenticate raddr and params
+func (c *CookieHMAC) mac(period int64, raddr net.Addr, params []byte) []byte {
+	derive := hmac.New(sha256.New, c.key)
+	_ = binary.Write(derive, binary.BigEndian, period)
+
+	mac := hmac.New(sha256.New, derive.Sum(nil))
+	if raddr != nil {
+		mac.Write([]byte(raddr.String()))
+	}
+	mac.Write(params)
+	return mac.Sum(nil)
+}
+
+// configCookies returns the CookieGenerator and CookieVerifier of config,
+// or a CookieHMAC with a random key if neither is set
+func configCookies(config *Config) (CookieGenerator, CookieVerifier, error) {
+	if config.CookieGenerator != nil && config.CookieVerifier != nil {
+		return config.CookieGenerator, config.CookieVerifier, nil
+	} else if config.CookieGenerator != nil || config.CookieVerifier != nil {
+		return nil, nil, errCookiePolicyIncomplete
+	}
+
+	cookies, err := NewCookieHMAC(nil, defaultCookieRotation, defaultCookieRotation)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cookies, cookies, nil
+}
+
+// cookieParams encodes everything the client MUST repeat in the
+// ClientHello that answers a HelloVerifyRequest
+// https://tools.ietf.org/html/rfc6347#section-4.2.1
+func cookieParams(h *handshakeMessageClientHello) []byte {
+	random, _ := h.random.Marshal()
+
+	out := []byte{h.version.major, h.version.minor}
+	out = append(out, random...)
+	out = append(out, byte(len(h.sessionID)))
+	out = append(out, h.sessionID...)
+	out = append(out, encodeCipherSuites(h.cipherSuites)...)
+	return append(out, encodeCompressionMethods(h.compressionMethods)...)
+}
@@ -0,0 +1,92 @@
+package dtls
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestCookieHMAC(t *testing.T) {
+	cookies, err := NewCookieHMAC(nil, time.Minute, 30*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raddr := &net.UDPAddr{IP: net.ParseIP("192.0.2.1"), Port: 4444}
+	clientHello := &handshakeMessageClientHello{
+		version:            protocolVersion1_2,
+		sessionID:          []byte{},
+		cipherSuites:       []cipherSuite{&cipherSuiteTLSEcdheEcdsaWithAes128GcmSha256{}},
+		compressionMethods: defaultCompressionMethods,
+	}
+	if err = clientHello.random.populate(); err != nil {
+		t.Fatal(err)
+	}
+	params := cookieParams(clientHello)
+
+	if cookies.VerifyCookie(raddr, params, nil) {
+		t.Error("CookieHMAC verify: ClientHello without a cookie verified")
+	}
+
+	cookie, err := cookies.GenerateCookie(raddr, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cookies.VerifyCookie(raddr, params, cookie) {
+		t.Error("CookieHMAC verify: valid cookie rejected")
+	}
+
+	otherAddr := &net.UDPAddr{IP: net.ParseIP("192.0.2.1"), Port: 4445}
+	if cookies.VerifyCookie(otherAddr, params, cookie) {
+		t.Error("CookieHMAC verify: cookie accepted from another address")
+	}
+
+	otherHello := *clientHello
+	otherHello.sessionID = []byte{0x01}
+	if cookies.VerifyCookie(raddr, cookieParams(&otherHello), cookie) {
+		t.Error("CookieHMAC verify: cookie accepted for another ClientHello")
+	}
+}
+
+func TestCookieHMACRotation(t *testing.T) {
+	key := []byte("cluster wide cookie key")
+	start := time.Unix(1000*60, 0)
+
+	issuer, err := NewCookieHMAC(key, time.Minute, 30*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer.now = func() time.Time { return start }
+
+	raddr := &net.UDPAddr{IP: net.ParseIP("192.0.2.1"), Port: 4444}
+	params := []byte{0x01, 0x02, 0x03}
+	cookie, err := issuer.GenerateCookie(raddr, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name   string
+		key    []byte
+		offset time.Duration
+		valid  bool
+	}{
+		{"same period", key, 59 * time.Second, true},
+		{"grace window", key, 89 * time.Second, true},
+		{"after grace window", key, 91 * time.Second, false},
+		{"clock behind", key, -29 * time.Second, true},
+		{"clock too far behind", key, -31 * time.Second, false},
+		{"other key", []byte("another cookie key"), 0, false},
+	} {
+		verifier, err := NewCookieHMAC(test.key, time.Minute, 30*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		now := start.Add(test.offset)
+		verifier.now = func() time.Time { return now }
+
+		if valid := verifier.VerifyCookie(raddr, params, cookie); valid != test.valid {
+			t.Errorf("CookieHMAC verify %s: got %t, want %t", test.name, valid, test.valid)
+		}
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"crypto/ecdsa"
 	"crypto/rand"
 	"crypto/rsa"
-	"crypto/sha256"
 	"crypto/x509"
 	"encoding/asn1"
 	"encoding/binary"
@@ -16,7 +15,7 @@ type ecdsaSignature struct {
 	R, S *big.Int
 }
 
-func valueKeySignature(clientRandom, serverRandom, publicKey []byte, namedCurve namedCurve, hashAlgorithm HashAlgorithm) []byte {
+func valueKeySignature(clientRandom, serverRandom, publicKey []byte, namedCurve NamedCurve, hashAlgorithm HashAlgorithm) []byte {
 	serverECDHParams := make([]byte, 4)
 	serverECDHParams[0] = 3 // named curve
 	binary.BigEndian.PutUint16(serverECDHParams[1:], uint16(namedCurve))
@@ -35,13 +34,13 @@ func valueKeySignature(clientRandom, serverRandom, publicKey []byte, namedCurve
 // hash/signature algorithm pair that appears in that extension
 //
 // https://tools.ietf.org/html/rfc5246#section-7.4.2
-func generateKeySignature(clientRandom, serverRandom, publicKey []byte, namedCurve namedCurve, privateKey crypto.PrivateKey, hashAlgorithm HashAlgorithm) ([]byte, error) {
+func generateKeySignature(clientRandom, serverRandom, publicKey []byte, namedCurve NamedCurve, privateKey crypto.PrivateKey, hashAlgorithm HashAlgorithm) ([]byte, error) {
 	hashed := valueKeySignature(clientRandom, serverRandom, publicKey, namedCurve, hashAlgorithm)
 	switch p := privateKey.(type) {
 	case *ecdsa.PrivateKey:
-		return p.Sign(rand.Reader, hashed, crypto.SHA256)
+		return p.Sign(rand.Reader, hashed, hashAlgorithm.cryptoHash())
 	case *rsa.PrivateKey:
-		return p.Sign(rand.Reader, hashed, crypto.SHA256)
+		return p.Sign(rand.Reader, hashed, hashAlgorithm.cryptoHash())
 	}
 
 	return nil, errKeySignatureGenerateUnimplemented
@@ -77,18 +76,17 @@ func verifyKeySignature(hash, remoteKeySignature []byte, certificate *x509.Certi
 // CertificateVerify message is sent to explicitly verify possession of
 // the private key in the certificate.
 // https://tools.ietf.org/html/rfc5246#section-7.3
-func generateCertificateVerify(handshakeBodies []byte, privateKey crypto.PrivateKey) ([]byte, error) {
-	h := sha256.New()
-	if _, err := h.Write(handshakeBodies); err != nil {
-		return nil, err
+func generateCertificateVerify(handshakeBodies []byte, privateKey crypto.PrivateKey, hashAlgorithm HashAlgorithm) ([]byte, error) {
+	hashed := hashAlgorithm.digest(handshakeBodies)
+	if hashed == nil {
+		return nil, errInvalidHashAlgorithm
 	}
-	hashed := h.Sum(nil)
 
 	switch p := privateKey.(type) {
 	case *ecdsa.PrivateKey:
-		return p.Sign(rand.Reader, hashed, crypto.SHA256)
+		return p.Sign(rand.Reader, hashed, hashAlgorithm.cryptoHash())
 	case *rsa.PrivateKey:
-		return p.Sign(rand.Reader, hashed, crypto.SHA256)
+		return p.Sign(rand.Reader, hashed, hashAlgorithm.cryptoHash())
 	}
 
 	return nil, errInvalidSignatureAlgorithm
@@ -5,7 +5,6 @@ import (
 	"crypto/cipher"
 	"crypto/hmac"
 	"crypto/rand"
-	"crypto/sha1" // #nosec
 	"encoding/binary"
 )
 
@@ -19,12 +18,13 @@ type cbcMode interface {
 type cryptoCBC struct {
 	writeCBC, readCBC cbcMode
 	writeMac, readMac []byte
-}
+	h                 hashFunc // HMAC hash, SHA1 or SHA256 depending on the cipherSuite
 
-// Currently hardcoded to be SHA1 only
-var cryptoCBCMacFunc = sha1.New
+	// MAC the ciphertext instead of the plaintext, https://tools.ietf.org/html/rfc7366
+	encryptThenMac bool
+}
 
-func newCryptoCBC(localKey, localWriteIV, localMac, remoteKey, remoteWriteIV, remoteMac []byte) (*cryptoCBC, error) {
+func newCryptoCBC(localKey, localWriteIV, localMac, remoteKey, remoteWriteIV, remoteMac []byte, h hashFunc, encryptThenMac bool) (*cryptoCBC, error) {
 	writeBlock, err := aes.NewCipher(localKey)
 	if err != nil {
 		return nil, err
@@ -41,10 +41,17 @@ func newCryptoCBC(localKey, localWriteIV, localMac, remoteKey, remoteWriteIV, re
 
 		readCBC: cipher.NewCBCDecrypter(readBlock, remoteWriteIV).(cbcMode),
 		readMac: remoteMac,
+
+		h:              h,
+		encryptThenMac: encryptThenMac,
 	}, nil
 }
 
 func (c *cryptoCBC) encrypt(pkt *recordLayer, raw []byte) ([]byte, error) {
+	if c.encryptThenMac {
+		return c.encryptThenMacEncrypt(pkt, raw)
+	}
+
 	hlen := recordLayerHeaderSize
 	if pkt.recordLayerHeader.cid != nil {
 		hlen += pkt.recordLayerHeader.cidLen
@@ -57,7 +64,7 @@ func (c *cryptoCBC) encrypt(pkt *recordLayer, raw []byte) ([]byte, error) {
 	// Generate + Append MAC
 	h := pkt.recordLayerHeader
 
-	MAC, err := prfMac(h.epoch, h.sequenceNumber, h.contentType, h.protocolVersion, h.cidLen, h.cid, payload, c.writeMac)
+	MAC, err := prfMac(h.epoch, h.sequenceNumber, h.contentType, h.protocolVersion, h.cidLen, h.cid, payload, c.writeMac, c.h)
 	if err != nil {
 		return nil, err
 	}
@@ -102,7 +109,7 @@ func (c *cryptoCBC) decrypt(in []byte) ([]byte, error) {
 
 	body := in[hlen:]
 	blockSize := c.readCBC.BlockSize()
-	mac := cryptoCBCMacFunc()
+	mac := c.h()
 
 	err := h.Unmarshal(in)
 	switch {
@@ -111,6 +118,8 @@ func (c *cryptoCBC) decrypt(in []byte) ([]byte, error) {
 	case h.contentType == contentTypeChangeCipherSpec:
 		// Nothing to encrypt with ChangeCipherSpec
 		return in, nil
+	case c.encryptThenMac:
+		return c.encryptThenMacDecrypt(&h, in[:hlen], body)
 	case len(body)%blockSize != 0 || len(body) < blockSize+max(mac.Size()+1, blockSize):
 		return nil, errNotEnoughRoomForNonce
 	}
@@ -127,14 +136,14 @@ func (c *cryptoCBC) decrypt(in []byte) ([]byte, error) {
 	paddingLen, paddingGood := examinePadding(body)
 
 	macSize := mac.Size()
-	if len(body) < macSize {
+	if len(body) < macSize+paddingLen {
 		return nil, errInvalidMAC
 	}
 
 	dataEnd := len(body) - macSize - paddingLen
 
 	expectedMAC := body[dataEnd : dataEnd+macSize]
-	actualMAC, err := prfMac(h.epoch, h.sequenceNumber, h.contentType, h.protocolVersion, h.cidLen, h.cid, body[:dataEnd], c.readMac)
+	actualMAC, err := prfMac(h.epoch, h.sequenceNumber, h.contentType, h.protocolVersion, h.cidLen, h.cid, body[:dataEnd], c.readMac, c.h)
 
 	// Compute Local MAC and compare
 	if paddingGood != 255 || err != nil || !hmac.Equal(actualMAC, expectedMAC) {
@@ -143,3 +152,84 @@ func (c *cryptoCBC) decrypt(in []byte) ([]byte, error) {
 
 	return append(in[:hlen], body[:dataEnd]...), nil
 }
+
+// With Encrypt-then-MAC the MAC covers the IV and the ciphertext and is sent
+// unencrypted after them. The padding can then only be examined once the
+// record is known to be authentic.
+// https://tools.ietf.org/html/rfc7366#section-3
+func (c *cryptoCBC) encryptThenMacEncrypt(pkt *recordLayer, raw []byte) ([]byte, error) {
+	hlen := recordLayerHeaderSize
+	if pkt.recordLayerHeader.cid != nil {
+		hlen += pkt.recordLayerHeader.cidLen
+	}
+
+	payload := raw[hlen:]
+	raw = raw[:hlen]
+	blockSize := c.writeCBC.BlockSize()
+
+	// Generate + Append padding
+	padding := make([]byte, blockSize-len(payload)%blockSize)
+	paddingLen := len(padding)
+	for i := 0; i < paddingLen; i++ {
+		padding[i] = byte(paddingLen - 1)
+	}
+	payload = append(append([]byte{}, payload...), padding...)
+
+	// Generate IV
+	iv := make([]byte, blockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	// Set IV + Encrypt + Prepend IV
+	c.writeCBC.SetIV(iv)
+	c.writeCBC.CryptBlocks(payload, payload)
+	payload = append(iv, payload...)
+
+	// Generate + Append MAC over IV+ciphertext
+	h := pkt.recordLayerHeader
+	MAC, err := prfMac(h.epoch, h.sequenceNumber, h.contentType, h.protocolVersion, h.cidLen, h.cid, payload, c.writeMac, c.h)
+	if err != nil {
+		return nil, err
+	}
+	payload = append(payload, MAC...)
+
+	// Prepend unencrypte header with encrypted payload
+	raw = append(raw, payload...)
+
+	// Update recordLayer size to include IV+Padding+MAC
+	binary.BigEndian.PutUint16(raw[hlen-2:], uint16(len(raw)-hlen))
+
+	return raw, nil
+}
+
+func (c *cryptoCBC) encryptThenMacDecrypt(h *recordLayerHeader, header, body []byte) ([]byte, error) {
+	blockSize := c.readCBC.BlockSize()
+	macSize := c.h().Size()
+
+	// IV + at least one block of ciphertext + MAC
+	if len(body) < 2*blockSize+macSize || (len(body)-macSize)%blockSize != 0 {
+		return nil, errNotEnoughRoomForNonce
+	}
+
+	ciphertext := body[:len(body)-macSize]
+	expectedMAC := body[len(body)-macSize:]
+	actualMAC, err := prfMac(h.epoch, h.sequenceNumber, h.contentType, h.protocolVersion, h.cidLen, h.cid, ciphertext, c.readMac, c.h)
+	if err != nil || !hmac.Equal(actualMAC, expectedMAC) {
+		return nil, errInvalidMAC
+	}
+
+	// Set + remove per record IV
+	c.readCBC.SetIV(ciphertext[:blockSize])
+	ciphertext = ciphertext[blockSize:]
+
+	// Decrypt
+	c.readCBC.CryptBlocks(ciphertext, ciphertext)
+
+	paddingLen, paddingGood := examinePadding(ciphertext)
+	if paddingGood != 255 {
+		return nil, errInvalidPadding
+	}
+
+	return append(header, ciphertext[:len(ciphertext)-paddingLen]...), nil
+}
@@ -0,0 +1,53 @@
+package dtls
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestCryptoCBCRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{0x01}, 16)
+	iv := bytes.Repeat([]byte{0x02}, 16)
+	mac := bytes.Repeat([]byte{0x03}, 32)
+
+	for _, encryptThenMac := range []bool{false, true} {
+		c, err := newCryptoCBC(key, iv, mac, key, iv, mac, sha256.New, encryptThenMac)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data := []byte("hello world, this is longer than a single block")
+		pkt := &recordLayer{
+			recordLayerHeader: recordLayerHeader{
+				epoch:           1,
+				sequenceNumber:  5,
+				protocolVersion: protocolVersion1_2,
+			},
+			content: &applicationData{data: data},
+		}
+		raw, err := pkt.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		encrypted, err := c.encrypt(pkt, raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tampered := append([]byte{}, encrypted...)
+		tampered[len(tampered)-1] ^= 0x01
+
+		decrypted, err := c.decrypt(encrypted)
+		if err != nil {
+			t.Fatalf("encryptThenMac(%t) decrypt: %v", encryptThenMac, err)
+		} else if !bytes.Equal(decrypted[recordLayerHeaderSize:], data) {
+			t.Errorf("encryptThenMac(%t) decrypt: got %#v, want %#v", encryptThenMac, decrypted[recordLayerHeaderSize:], data)
+		}
+
+		if _, err := c.decrypt(tampered); err != errInvalidMAC {
+			t.Errorf("encryptThenMac(%t) decrypt tampered: expected '%v' actual '%v'", encryptThenMac, errInvalidMAC, err)
+		}
+	}
+}
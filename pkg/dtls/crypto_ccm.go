@@ -0,0 +1,34 @@
+package dtls
+
+import (
+	"crypto/aes"
+
+	"github.com/thomas-fossati/dtls/pkg/dtls/internal/ccm"
+)
+
+const (
+	cryptoCCM8TagLength  = 8
+	cryptoCCMNonceLength = 12
+)
+
+func newCryptoCCM(tagLen int, localKey, localWriteIV, remoteKey, remoteWriteIV []byte) (*cryptoAEAD, error) {
+	localBlock, err := aes.NewCipher(localKey)
+	if err != nil {
+		return nil, err
+	}
+	localCCM, err := ccm.NewCCM(localBlock, tagLen, cryptoCCMNonceLength)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteBlock, err := aes.NewCipher(remoteKey)
+	if err != nil {
+		return nil, err
+	}
+	remoteCCM, err := ccm.NewCCM(remoteBlock, tagLen, cryptoCCMNonceLength)
+	if err != nil {
+		return nil, err
+	}
+
+	return newCryptoAEAD(tagLen, localCCM, localWriteIV, remoteCCM, remoteWriteIV), nil
+}
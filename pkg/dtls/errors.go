@@ -8,15 +8,19 @@ var (
 
 	errBufferTooSmall                    = errors.New("dtls: buffer is too small")
 	errCertificateUnset                  = errors.New("dtls: handshakeMessageCertificate can not be marshalled without a certificate")
+	errClientRequiredButNoServerEMS      = errors.New("dtls: client required Extended Master Secret extension, but server does not support it")
 	errCipherSuiteNoIntersection         = errors.New("dtls: Client+Server do not support any shared cipher suites")
 	errCipherSuiteUnset                  = errors.New("dtls: server hello can not be created without a cipher suite")
+	errClosedListener                    = errors.New("dtls: listener closed")
 	errCompressionmethodUnset            = errors.New("dtls: server hello can not be created without a compression method")
-	errContextUnsupported                = errors.New("dtls: context is not supported for ExportKeyingMaterial")
+	errContextTooLong                    = errors.New("dtls: ExportKeyingMaterial context must be shorter than 64KB")
 	errCookieMismatch                    = errors.New("dtls: Client+Server cookie does not match")
+	errCookiePolicyIncomplete            = errors.New("dtls: CookieGenerator and CookieVerifier must be set together")
 	errCookieTooLong                     = errors.New("dtls: cookie must not be longer then 255 bytes")
 	errDTLSPacketInvalidLength           = errors.New("dtls: packet is too short")
 	errHandshakeInProgress               = errors.New("dtls: Handshake is in progress")
 	errHandshakeMessageUnset             = errors.New("dtls: handshake message unset, unable to marshal")
+	errHandshakeTimeout                  = errors.New("dtls: the handshake timed out")
 	errInvalidCipherSpec                 = errors.New("dtls: cipher spec invalid")
 	errInvalidCipherSuite                = errors.New("dtls: invalid or unknown cipher suite")
 	errInvalidCompressionMethod          = errors.New("dtls: invalid or unknown compression method")
@@ -27,20 +31,34 @@ var (
 	errInvalidHashAlgorithm              = errors.New("dtls: invalid hash algorithm")
 	errInvalidMAC                        = errors.New("dtls: invalid mac")
 	errInvalidNamedCurve                 = errors.New("dtls: invalid named curve")
+	errInvalidPadding                    = errors.New("dtls: invalid padding")
 	errInvalidPrivateKey                 = errors.New("dtls: invalid private key type")
+	errInvalidSRTPProfile                = errors.New("dtls: invalid or unknown SRTP protection profile")
 	errInvalidSignatureAlgorithm         = errors.New("dtls: invalid signature algorithm")
 	errKeySignatureGenerateUnimplemented = errors.New("dtls: Unable to generate key signature, unimplemented")
 	errKeySignatureMismatch              = errors.New("dtls: Expected and actual key signature do not match")
 	errKeySignatureVerifyUnimplemented   = errors.New("dtls: Unable to verify key signature, unimplemented")
 	errLengthMismatch                    = errors.New("dtls: data length and declared length do not match")
+	errMessageTooLong                    = errors.New("dtls: message is longer than the maximum record size")
 	errNilNextConn                       = errors.New("dtls: Conn can not be created with a nil nextConn")
+	errNoAvailableCipherSuites           = errors.New("dtls: connection can not be created, no CipherSuites satisfy this Config")
+	errNoSRTPProtectionProfile           = errors.New("dtls: no SRTP protection profile was negotiated")
 	errNotEnoughRoomForNonce             = errors.New("dtls: Buffer not long enough to contain nonce")
 	errNotImplemented                    = errors.New("dtls: feature has not been implemented yet")
 	errReservedExportKeyingMaterial      = errors.New("dtls: ExportKeyingMaterial can not be used with a reserved label")
+	errResumedSessionEMSMismatch         = errors.New("dtls: resumed session does not match the Extended Master Secret state of the original session")
 	errSequenceNumberOverflow            = errors.New("dtls: sequence number overflow")
-	errServerMustHaveCertificate         = errors.New("dtls: Certificate is mandatory for server")
+	errServerRequiredButNoClientEMS      = errors.New("dtls: server required Extended Master Secret extension, but client does not support it")
+	errServerSentUnrequestedEtM          = errors.New("dtls: server sent Encrypt-then-MAC extension for a cipher suite that doesn't support it")
+	errServerSentUnrequestedSRTP         = errors.New("dtls: server sent use_srtp extension with a profile the client did not offer")
+	errServerSentUnrequestedTicket       = errors.New("dtls: server sent SessionTicket extension, but client did not request it")
+	errServerSentUnrequestedEMS          = errors.New("dtls: server sent Extended Master Secret extension, but client did not request it")
+	errServerMustHaveCertificateOrPSK    = errors.New("dtls: Certificate or PSK is mandatory for server")
+	errSessionIDTooLong                  = errors.New("dtls: session id must not be longer then 32 bytes")
+	errServerKeyExchangeMissing          = errors.New("dtls: server did not send a ServerKeyExchange")
 	errUnableToMarshalFragmented         = errors.New("dtls: unable to marshal fragmented handshakes")
 	errVerifyDataMismatch                = errors.New("dtls: Expected and actual verify data does not match")
+	errWriteClosed                       = errors.New("dtls: conn is closed for writing")
 	errConnectionIdTooBig                = errors.New("dtls: the supplied connection id is bigger than 255 bytes")
 	errNotEnoughDataForCid               = errors.New("dtls: there are not enough bytes in the record header to hold the connection id")
 )
@@ -11,6 +11,9 @@ const (
 	extensionSupportedEllipticCurvesValue extensionValue = 10
 	extensionSupportedPointFormatsValue   extensionValue = 11
 	extensionUseSRTPValue                 extensionValue = 14
+	extensionEncryptThenMacValue          extensionValue = 22
+	extensionExtendedMasterSecretValue    extensionValue = 23
+	extensionSessionTicketValue           extensionValue = 35
 	extensionConnectionIdValue            extensionValue = 52 // provisional
 )
 
@@ -22,6 +25,9 @@ type extension interface {
 }
 
 func decodeExtensions(buf []byte) ([]extension, error) {
+	if len(buf) < 2 {
+		return nil, errBufferTooSmall
+	}
 	declaredLen := binary.BigEndian.Uint16(buf)
 	if len(buf)-2 != int(declaredLen) {
 		return nil, errLengthMismatch
@@ -38,12 +44,22 @@ func decodeExtensions(buf []byte) ([]extension, error) {
 	}
 
 	for offset := 2; offset < len(buf); {
+		if len(buf) < offset+4 {
+			return nil, errBufferTooSmall
+		}
+
 		var err error
 		switch extensionValue(binary.BigEndian.Uint16(buf[offset:])) {
 		case extensionSupportedEllipticCurvesValue:
 			err = unmarshalAndAppend(buf[offset:], &extensionSupportedEllipticCurves{})
 		case extensionUseSRTPValue:
 			err = unmarshalAndAppend(buf[offset:], &extensionUseSRTP{})
+		case extensionEncryptThenMacValue:
+			err = unmarshalAndAppend(buf[offset:], &extensionEncryptThenMac{})
+		case extensionExtendedMasterSecretValue:
+			err = unmarshalAndAppend(buf[offset:], &extensionExtendedMasterSecret{})
+		case extensionSessionTicketValue:
+			err = unmarshalAndAppend(buf[offset:], &extensionSessionTicket{})
 		case extensionConnectionIdValue:
 			err = unmarshalAndAppend(buf[offset:], &extensionConnectionId{})
 		default:
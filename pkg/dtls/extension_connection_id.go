@@ -42,7 +42,13 @@ func (e *extensionConnectionId) Unmarshal(data []byte) error {
 		return errInvalidExtensionType
 	}
 
-	e.connectionId = append(e.connectionId, data[extensionConnectionIdHeaderSize:]...)
+	// data holds the extensions that follow this one too, only take
+	// as many bytes as the CID length says
+	cidLen := int(data[extensionConnectionIdHeaderSize-1])
+	if len(data) < extensionConnectionIdHeaderSize+cidLen {
+		return errBufferTooSmall
+	}
+	e.connectionId = append(e.connectionId, data[extensionConnectionIdHeaderSize:extensionConnectionIdHeaderSize+cidLen]...)
 
 	return nil
 }
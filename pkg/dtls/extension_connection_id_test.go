@@ -85,6 +85,18 @@ func TestConnectionIdUnmarshal(t *testing.T) {
 			ex: []byte{0x00, 0x01, 0x02},
 			er: nil,
 		},
+		testVector{
+			// followed by another extension
+			in: []byte{0x00, 0x34, 0x00, 0x04, 0x03, 0x00, 0x01, 0x02, 0x00, 0x17, 0x00, 0x00},
+			ex: []byte{0x00, 0x01, 0x02},
+			er: nil,
+		},
+		testVector{
+			// truncated CID
+			in: []byte{0x00, 0x34, 0x00, 0x04, 0x03, 0x00, 0x01},
+			ex: nil, // doesn't matter
+			er: errBufferTooSmall,
+		},
 	}
 
 	for _, tv := range tvs {
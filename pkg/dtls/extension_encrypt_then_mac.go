@@ -0,0 +1,40 @@
+package dtls
+
+import (
+	"encoding/binary"
+)
+
+const (
+	extensionEncryptThenMacHeaderSize = 4
+)
+
+// The encrypt_then_mac extension has an empty body, it is only negotiated
+// for block ciphers (CBC)
+//
+// https://tools.ietf.org/html/rfc7366#section-2
+type extensionEncryptThenMac struct {
+}
+
+func (e extensionEncryptThenMac) extensionValue() extensionValue {
+	return extensionEncryptThenMacValue
+}
+
+func (e *extensionEncryptThenMac) Marshal() ([]byte, error) {
+	out := make([]byte, extensionEncryptThenMacHeaderSize)
+
+	binary.BigEndian.PutUint16(out, uint16(e.extensionValue()))
+	binary.BigEndian.PutUint16(out[2:], 0) // length
+	return out, nil
+}
+
+func (e *extensionEncryptThenMac) Unmarshal(data []byte) error {
+	if len(data) < extensionEncryptThenMacHeaderSize {
+		return errBufferTooSmall
+	} else if extensionValue(binary.BigEndian.Uint16(data)) != e.extensionValue() {
+		return errInvalidExtensionType
+	} else if binary.BigEndian.Uint16(data[2:]) != 0 {
+		return errLengthMismatch
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package dtls
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtensionEncryptThenMac(t *testing.T) {
+	rawEncryptThenMac := []byte{0x00, 0x16, 0x00, 0x00}
+
+	e := &extensionEncryptThenMac{}
+	if err := e.Unmarshal(rawEncryptThenMac); err != nil {
+		t.Error(err)
+	}
+
+	raw, err := e.Marshal()
+	if err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(raw, rawEncryptThenMac) {
+		t.Errorf("extensionEncryptThenMac marshal: got %#v, want %#v", raw, rawEncryptThenMac)
+	}
+
+	if err := e.Unmarshal([]byte{0x00, 0x17, 0x00, 0x00}); err != errInvalidExtensionType {
+		t.Errorf("extensionEncryptThenMac unmarshal with wrong type: expected '%v' actual '%v'", errInvalidExtensionType, err)
+	}
+}
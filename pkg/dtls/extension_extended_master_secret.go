@@ -0,0 +1,40 @@
+package dtls
+
+import (
+	"encoding/binary"
+)
+
+const (
+	extensionExtendedMasterSecretHeaderSize = 4
+)
+
+// The extended_master_secret extension has an empty body, it only
+// signals support for the session hash based master secret
+//
+// https://tools.ietf.org/html/rfc7627#section-5.1
+type extensionExtendedMasterSecret struct {
+}
+
+func (e extensionExtendedMasterSecret) extensionValue() extensionValue {
+	return extensionExtendedMasterSecretValue
+}
+
+func (e *extensionExtendedMasterSecret) Marshal() ([]byte, error) {
+	out := make([]byte, extensionExtendedMasterSecretHeaderSize)
+
+	binary.BigEndian.PutUint16(out, uint16(e.extensionValue()))
+	binary.BigEndian.PutUint16(out[2:], 0) // length
+	return out, nil
+}
+
+func (e *extensionExtendedMasterSecret) Unmarshal(data []byte) error {
+	if len(data) < extensionExtendedMasterSecretHeaderSize {
+		return errBufferTooSmall
+	} else if extensionValue(binary.BigEndian.Uint16(data)) != e.extensionValue() {
+		return errInvalidExtensionType
+	} else if binary.BigEndian.Uint16(data[2:]) != 0 {
+		return errLengthMismatch
+	}
+
+	return nil
+}
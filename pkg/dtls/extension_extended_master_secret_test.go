@@ -0,0 +1,26 @@
+package dtls
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtendedMasterSecretExtension(t *testing.T) {
+	rawExtendedMasterSecret := []byte{0x00, 0x17, 0x00, 0x00}
+
+	e := &extensionExtendedMasterSecret{}
+	if err := e.Unmarshal(rawExtendedMasterSecret); err != nil {
+		t.Error(err)
+	}
+
+	raw, err := e.Marshal()
+	if err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(raw, rawExtendedMasterSecret) {
+		t.Errorf("extensionExtendedMasterSecret marshal: got %#v, want %#v", raw, rawExtendedMasterSecret)
+	}
+
+	if err := e.Unmarshal([]byte{0x00, 0x17, 0x00, 0x01, 0x00}); err != errLengthMismatch {
+		t.Errorf("extensionExtendedMasterSecret unmarshal with body: expected '%v' actual '%v'", errLengthMismatch, err)
+	}
+}
@@ -0,0 +1,45 @@
+package dtls
+
+import (
+	"encoding/binary"
+)
+
+const (
+	extensionSessionTicketHeaderSize = 4
+)
+
+// The SessionTicket extension is empty when a client requests a ticket
+// or a server announces it will send one, and carries the ticket when a
+// client offers it for resumption
+//
+// https://tools.ietf.org/html/rfc5077#section-3.2
+type extensionSessionTicket struct {
+	ticket []byte
+}
+
+func (e extensionSessionTicket) extensionValue() extensionValue {
+	return extensionSessionTicketValue
+}
+
+func (e *extensionSessionTicket) Marshal() ([]byte, error) {
+	out := make([]byte, extensionSessionTicketHeaderSize)
+
+	binary.BigEndian.PutUint16(out, uint16(e.extensionValue()))
+	binary.BigEndian.PutUint16(out[2:], uint16(len(e.ticket)))
+	return append(out, e.ticket...), nil
+}
+
+func (e *extensionSessionTicket) Unmarshal(data []byte) error {
+	if len(data) < extensionSessionTicketHeaderSize {
+		return errBufferTooSmall
+	} else if extensionValue(binary.BigEndian.Uint16(data)) != e.extensionValue() {
+		return errInvalidExtensionType
+	}
+
+	ticketLength := int(binary.BigEndian.Uint16(data[2:]))
+	if len(data) < extensionSessionTicketHeaderSize+ticketLength {
+		return errBufferTooSmall
+	}
+	e.ticket = append([]byte{}, data[extensionSessionTicketHeaderSize:extensionSessionTicketHeaderSize+ticketLength]...)
+	return nil
+}
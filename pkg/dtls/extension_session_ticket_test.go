@@ -0,0 +1,41 @@
+package dtls
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtensionSessionTicket(t *testing.T) {
+	for _, test := range []struct {
+		raw    []byte
+		parsed *extensionSessionTicket
+	}{
+		{
+			raw:    []byte{0x00, 0x23, 0x00, 0x00},
+			parsed: &extensionSessionTicket{ticket: []byte{}},
+		},
+		{
+			raw:    []byte{0x00, 0x23, 0x00, 0x03, 0x01, 0x02, 0x03},
+			parsed: &extensionSessionTicket{ticket: []byte{0x01, 0x02, 0x03}},
+		},
+	} {
+		e := &extensionSessionTicket{}
+		if err := e.Unmarshal(test.raw); err != nil {
+			t.Error(err)
+		} else if !reflect.DeepEqual(e, test.parsed) {
+			t.Errorf("extensionSessionTicket unmarshal: got %#v, want %#v", e, test.parsed)
+		}
+
+		raw, err := e.Marshal()
+		if err != nil {
+			t.Error(err)
+		} else if !reflect.DeepEqual(raw, test.raw) {
+			t.Errorf("extensionSessionTicket marshal: got %#v, want %#v", raw, test.raw)
+		}
+	}
+
+	e := &extensionSessionTicket{}
+	if err := e.Unmarshal([]byte{0x00, 0x23, 0x00, 0x03, 0x01}); err != errBufferTooSmall {
+		t.Errorf("extensionSessionTicket unmarshal truncated: got %v, want %v", err, errBufferTooSmall)
+	}
+}
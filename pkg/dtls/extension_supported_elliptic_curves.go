@@ -10,7 +10,7 @@ const (
 
 // https://tools.ietf.org/html/rfc8422#section-5.1.1
 type extensionSupportedEllipticCurves struct {
-	ellipticCurves []namedCurve
+	ellipticCurves []NamedCurve
 }
 
 func (e extensionSupportedEllipticCurves) extensionValue() extensionValue {
@@ -45,7 +45,7 @@ func (e *extensionSupportedEllipticCurves) Unmarshal(data []byte) error {
 	}
 
 	for i := 0; i < groupCount; i++ {
-		supportedGroupID := namedCurve(binary.BigEndian.Uint16(data[(extensionSupportedGroupsHeaderSize + (i * 2)):]))
+		supportedGroupID := NamedCurve(binary.BigEndian.Uint16(data[(extensionSupportedGroupsHeaderSize + (i * 2)):]))
 		if _, ok := namedCurves[supportedGroupID]; ok {
 			e.ellipticCurves = append(e.ellipticCurves, supportedGroupID)
 		}
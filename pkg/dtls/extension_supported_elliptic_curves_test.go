@@ -8,7 +8,7 @@ import (
 func TestExtensionSupportedGroups(t *testing.T) {
 	rawSupportedGroups := []byte{0x0, 0xa, 0x0, 0x4, 0x0, 0x2, 0x0, 0x1d}
 	parsedSupportedGroups := &extensionSupportedEllipticCurves{
-		ellipticCurves: []namedCurve{namedCurveX25519},
+		ellipticCurves: []NamedCurve{NamedCurveX25519},
 	}
 
 	raw, err := parsedSupportedGroups.Marshal()
@@ -6,9 +6,9 @@ const (
 	extensionUseSRTPHeaderSize = 6
 )
 
-// https://tools.ietf.org/html/rfc8422
+// https://tools.ietf.org/html/rfc5764#section-4.1.1
 type extensionUseSRTP struct {
-	protectionProfiles []srtpProtectionProfile
+	protectionProfiles []SRTPProtectionProfile
 }
 
 func (e extensionUseSRTP) extensionValue() extensionValue {
@@ -39,12 +39,12 @@ func (e *extensionUseSRTP) Unmarshal(data []byte) error {
 	}
 
 	profileCount := int(binary.BigEndian.Uint16(data[4:]) / 2)
-	if extensionSupportedGroupsHeaderSize+(profileCount*2) > len(data) {
+	if extensionUseSRTPHeaderSize+(profileCount*2)+ /* MKI Length */ 1 > len(data) {
 		return errLengthMismatch
 	}
 
 	for i := 0; i < profileCount; i++ {
-		supportedProfile := srtpProtectionProfile(binary.BigEndian.Uint16(data[(extensionUseSRTPHeaderSize + (i * 2)):]))
+		supportedProfile := SRTPProtectionProfile(binary.BigEndian.Uint16(data[(extensionUseSRTPHeaderSize + (i * 2)):]))
 		if _, ok := srtpProtectionProfiles[supportedProfile]; ok {
 			e.protectionProfiles = append(e.protectionProfiles, supportedProfile)
 		}
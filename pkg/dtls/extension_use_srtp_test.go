@@ -8,7 +8,7 @@ import (
 func TestExtensionUseSRTP(t *testing.T) {
 	rawUseSRTP := []byte{0x00, 0x0e, 0x00, 0x05, 0x00, 0x02, 0x00, 0x01, 0x00}
 	parsedUseSRTP := &extensionUseSRTP{
-		protectionProfiles: []srtpProtectionProfile{SRTP_AES128_CM_HMAC_SHA1_80},
+		protectionProfiles: []SRTPProtectionProfile{SRTP_AES128_CM_HMAC_SHA1_80},
 	}
 
 	raw, err := parsedUseSRTP.Marshal()
@@ -18,3 +18,22 @@ func TestExtensionUseSRTP(t *testing.T) {
 		t.Errorf("extensionUseSRTP marshal: got %#v, want %#v", raw, rawUseSRTP)
 	}
 }
+
+func TestExtensionUseSRTPUnmarshal(t *testing.T) {
+	// An unknown profile is skipped
+	rawUseSRTP := []byte{0x00, 0x0e, 0x00, 0x07, 0x00, 0x04, 0x00, 0x07, 0xff, 0xff, 0x00}
+	parsedUseSRTP := &extensionUseSRTP{
+		protectionProfiles: []SRTPProtectionProfile{SRTP_AEAD_AES_128_GCM},
+	}
+
+	e := &extensionUseSRTP{}
+	if err := e.Unmarshal(rawUseSRTP); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(e, parsedUseSRTP) {
+		t.Errorf("extensionUseSRTP unmarshal: got %#v, want %#v", e, parsedUseSRTP)
+	}
+
+	if err := (&extensionUseSRTP{}).Unmarshal(rawUseSRTP[:9]); err != errLengthMismatch {
+		t.Errorf("extensionUseSRTP unmarshal truncated: got %v, want %v", err, errLengthMismatch)
+	}
+}
@@ -33,6 +33,25 @@ import "sync"
                                       [ChangeCipherSpec]    \ Flight 6
                           <--------             Finished    /
 
+  A Listener sends the HelloVerifyRequest without keeping any state, the
+  Conn is only created for the ClientHello carrying a valid cookie and
+  starts at Flight 4 right away. With InsecureSkipHelloVerify the server
+  answers the first ClientHello with Flight 4.
+  https://tools.ietf.org/html/rfc6347#section-4.2.1
+
+  When the client offers a session ID the server knows, the session is
+  resumed with an abbreviated handshake, where the server finishes first.
+  https://tools.ietf.org/html/rfc5246#section-7.3
+
+  ClientHello             -------->                           Flight 3
+
+                                             ServerHello    \
+                                      [ChangeCipherSpec]     Flight 4
+                          <--------             Finished    /
+
+  [ChangeCipherSpec]                                         \ Flight 5
+  Finished                -------->                          /
+
 */
 
 type flightVal uint8
@@ -72,6 +91,7 @@ type flight struct {
 	sync.RWMutex
 	val           flightVal
 	workerTrigger chan struct{} // Temporary way to trigger next flight
+	onChange      func(from, to flightVal)
 }
 
 func newFlight(isClient bool) *flight {
@@ -90,9 +110,14 @@ func (f *flight) get() flightVal {
 
 func (f *flight) set(val flightVal) error {
 	f.Lock()
+	from := f.val
 	f.val = val // TODO ensure no invalid transitions
 	f.Unlock()
 
+	if f.onChange != nil && from != val {
+		f.onChange(from, val)
+	}
+
 	select {
 	case f.workerTrigger <- struct{}{}:
 	default:
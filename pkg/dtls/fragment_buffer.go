@@ -12,6 +12,7 @@ type fragmentBuffer struct {
 
 	currentEpoch                 uint16
 	currentMessageSequenceNumber uint16
+	started                      bool // a message has been popped
 }
 
 func newFragmentBuffer() *fragmentBuffer {
@@ -48,6 +49,13 @@ func (f *fragmentBuffer) push(buf []byte) (bool, error) {
 		return false, nil
 	}
 
+	// A Listener answers the first ClientHello statelessly, the Conn then
+	// starts with the ClientHello that carries the cookie, at message_seq 1
+	// https://tools.ietf.org/html/rfc6347#section-4.2.2
+	if !f.started && frag.handshakeHeader.handshakeType == handshakeTypeClientHello {
+		f.currentMessageSequenceNumber = frag.handshakeHeader.messageSequence
+	}
+
 	if _, ok := f.cache[frag.handshakeHeader.messageSequence]; !ok {
 		f.cache[frag.handshakeHeader.messageSequence] = []*fragment{}
 	}
@@ -102,5 +110,6 @@ func (f *fragmentBuffer) pop() ([]byte, uint16) {
 
 	delete(f.cache, f.currentMessageSequenceNumber)
 	f.currentMessageSequenceNumber++
+	f.started = true
 	return append(rawHeader, rawMessage...), f.currentEpoch
 }
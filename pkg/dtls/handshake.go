@@ -1,5 +1,7 @@
 package dtls
 
+import "fmt"
+
 // https://tools.ietf.org/html/rfc5246#section-7.4
 type handshakeType uint8
 
@@ -8,6 +10,7 @@ const (
 	handshakeTypeClientHello        handshakeType = 1
 	handshakeTypeServerHello        handshakeType = 2
 	handshakeTypeHelloVerifyRequest handshakeType = 3
+	handshakeTypeNewSessionTicket   handshakeType = 4
 	handshakeTypeCertificate        handshakeType = 11
 	handshakeTypeServerKeyExchange  handshakeType = 12
 	handshakeTypeCertificateRequest handshakeType = 13
@@ -21,6 +24,37 @@ const (
 	handshakeMessageHeaderLength = 12
 )
 
+func (t handshakeType) String() string {
+	switch t {
+	case handshakeTypeHelloRequest:
+		return "HelloRequest"
+	case handshakeTypeClientHello:
+		return "ClientHello"
+	case handshakeTypeServerHello:
+		return "ServerHello"
+	case handshakeTypeHelloVerifyRequest:
+		return "HelloVerifyRequest"
+	case handshakeTypeNewSessionTicket:
+		return "NewSessionTicket"
+	case handshakeTypeCertificate:
+		return "Certificate"
+	case handshakeTypeServerKeyExchange:
+		return "ServerKeyExchange"
+	case handshakeTypeCertificateRequest:
+		return "CertificateRequest"
+	case handshakeTypeServerHelloDone:
+		return "ServerHelloDone"
+	case handshakeTypeCertificateVerify:
+		return "CertificateVerify"
+	case handshakeTypeClientKeyExchange:
+		return "ClientKeyExchange"
+	case handshakeTypeFinished:
+		return "Finished"
+	default:
+		return fmt.Sprintf("Unknown(%d)", uint8(t))
+	}
+}
+
 type handshakeMessage interface {
 	Marshal() ([]byte, error)
 	Unmarshal(data []byte) error
@@ -37,6 +71,10 @@ type handshakeMessage interface {
 type handshake struct {
 	handshakeHeader  handshakeHeader
 	handshakeMessage handshakeMessage
+
+	// keyExchangeAlgorithm of the negotiated cipherSuite, needed to
+	// Unmarshal the ServerKeyExchange and ClientKeyExchange messages
+	keyExchangeAlgorithm keyExchangeAlgorithm
 }
 
 func (h handshake) contentType() contentType {
@@ -87,16 +125,18 @@ func (h *handshake) Unmarshal(data []byte) error {
 		h.handshakeMessage = &handshakeMessageHelloVerifyRequest{}
 	case handshakeTypeServerHello:
 		h.handshakeMessage = &handshakeMessageServerHello{}
+	case handshakeTypeNewSessionTicket:
+		h.handshakeMessage = &handshakeMessageNewSessionTicket{}
 	case handshakeTypeCertificate:
 		h.handshakeMessage = &handshakeMessageCertificate{}
 	case handshakeTypeServerKeyExchange:
-		h.handshakeMessage = &handshakeMessageServerKeyExchange{}
+		h.handshakeMessage = &handshakeMessageServerKeyExchange{keyExchangeAlgorithm: h.keyExchangeAlgorithm}
 	case handshakeTypeCertificateRequest:
 		h.handshakeMessage = &handshakeMessageCertificateRequest{}
 	case handshakeTypeServerHelloDone:
 		h.handshakeMessage = &handshakeMessageServerHelloDone{}
 	case handshakeTypeClientKeyExchange:
-		h.handshakeMessage = &handshakeMessageClientKeyExchange{}
+		h.handshakeMessage = &handshakeMessageClientKeyExchange{keyExchangeAlgorithm: h.keyExchangeAlgorithm}
 	case handshakeTypeFinished:
 		h.handshakeMessage = &handshakeMessageFinished{}
 	default:
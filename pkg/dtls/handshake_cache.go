@@ -37,6 +37,16 @@ type handshakeCacheExcludeRule struct {
 	isRemote bool // Exclude handshake if remote sent
 }
 
+// sessionHash is the hash of the combined handshake, used by the
+// Extended Master Secret https://tools.ietf.org/html/rfc7627#section-3
+func (h *handshakeCache) sessionHash(hf hashFunc, excludeRules map[flightVal]handshakeCacheExcludeRule) ([]byte, error) {
+	hash := hf()
+	if _, err := hash.Write(h.combinedHandshake(excludeRules, false)); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
+
 func (h *handshakeCache) combinedHandshake(excludeRules map[flightVal]handshakeCacheExcludeRule, excludeLast bool) []byte {
 	out := make([]byte, 0)
 	lastIndex := len(h.cache) - 1 // Safe if len(h.cache) == 0, no loop will occur
@@ -2,6 +2,7 @@ package dtls
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"testing"
 )
 
@@ -65,3 +66,23 @@ func TestHandshakeCacheSinglePush(t *testing.T) {
 		}
 	}
 }
+
+func TestHandshakeCacheSessionHash(t *testing.T) {
+	h := newHandshakeCache()
+	h.push([]byte{0x00}, 0, 0, true, flight1)
+	h.push([]byte{0x01}, 0, 0, false, flight2)
+	h.push([]byte{0x02}, 0, 1, true, flight3)
+
+	excludeRules := map[flightVal]handshakeCacheExcludeRule{
+		flight1: {isLocal: true, isRemote: true},
+		flight2: {isLocal: true, isRemote: true},
+	}
+	expected := sha256.Sum256([]byte{0x02})
+
+	sessionHash, err := h.sessionHash(sha256.New, excludeRules)
+	if err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(sessionHash, expected[:]) {
+		t.Errorf("sessionHash: got % 02x, want % 02x", sessionHash, expected)
+	}
+}
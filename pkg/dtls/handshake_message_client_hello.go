@@ -12,9 +12,10 @@ initiative in order to renegotiate the security parameters in an
 existing connection.
 */
 type handshakeMessageClientHello struct {
-	version protocolVersion
-	random  handshakeRandom
-	cookie  []byte
+	version   protocolVersion
+	random    handshakeRandom
+	sessionID []byte
+	cookie    []byte
 
 	cipherSuites       []cipherSuite
 	compressionMethods []*compressionMethod
@@ -30,6 +31,8 @@ func (h handshakeMessageClientHello) handshakeType() handshakeType {
 func (h *handshakeMessageClientHello) Marshal() ([]byte, error) {
 	if len(h.cookie) > 255 {
 		return nil, errCookieTooLong
+	} else if len(h.sessionID) > sessionIDLength {
+		return nil, errSessionIDTooLong
 	}
 
 	out := make([]byte, handshakeMessageClientHelloVariableWidthStart)
@@ -42,7 +45,8 @@ func (h *handshakeMessageClientHello) Marshal() ([]byte, error) {
 	}
 	copy(out[2:], rand)
 
-	out = append(out, 0x00) // SessionID
+	out = append(out, byte(len(h.sessionID)))
+	out = append(out, h.sessionID...)
 
 	out = append(out, byte(len(h.cookie)))
 	out = append(out, h.cookie...)
@@ -58,6 +62,10 @@ func (h *handshakeMessageClientHello) Marshal() ([]byte, error) {
 }
 
 func (h *handshakeMessageClientHello) Unmarshal(data []byte) error {
+	if len(data) < 2+handshakeRandomLength {
+		return errBufferTooSmall
+	}
+
 	h.version.major = data[0]
 	h.version.minor = data[1]
 
@@ -67,13 +75,24 @@ func (h *handshakeMessageClientHello) Unmarshal(data []byte) error {
 
 	// rest of packet has variable width sections
 	currOffset := handshakeMessageClientHelloVariableWidthStart
-	currOffset += int(data[currOffset]) + 1 // SessionID
+	if len(data) <= currOffset || len(data) <= currOffset+int(data[currOffset]) {
+		return errBufferTooSmall
+	}
+	currOffset++
+	h.sessionID = append([]byte{}, data[currOffset:currOffset+int(data[currOffset-1])]...)
+	currOffset += len(h.sessionID)
 
+	if len(data) <= currOffset || len(data) <= currOffset+int(data[currOffset]) {
+		return errBufferTooSmall
+	}
 	currOffset++
 	h.cookie = append([]byte{}, data[currOffset:currOffset+int(data[currOffset-1])]...)
 	currOffset += len(h.cookie)
 
 	// Cipher Suites
+	if len(data) < currOffset+2 {
+		return errBufferTooSmall
+	}
 	cipherSuites, err := decodeCipherSuites(data[currOffset:])
 	if err != nil {
 		return err
@@ -82,6 +101,9 @@ func (h *handshakeMessageClientHello) Unmarshal(data []byte) error {
 	currOffset += int(binary.BigEndian.Uint16(data[currOffset:])) + 2
 
 	// Compression Methods
+	if len(data) <= currOffset {
+		return errBufferTooSmall
+	}
 	compressionMethods, err := decodeCompressionMethods(data[currOffset:])
 	if err != nil {
 		return err
@@ -90,6 +112,9 @@ func (h *handshakeMessageClientHello) Unmarshal(data []byte) error {
 	currOffset += int(data[currOffset]) + 1
 
 	// Extensions
+	if len(data) < currOffset {
+		return errBufferTooSmall
+	}
 	extensions, err := decodeExtensions(data[currOffset:])
 	if err != nil {
 		return err
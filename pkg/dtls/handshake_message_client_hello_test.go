@@ -44,7 +44,8 @@ func TestHandshakeMessageClientHello(t *testing.T) {
 			time.Unix(3056586332, 0),
 			[28]byte{0x42, 0x54, 0xff, 0x86, 0xe1, 0x24, 0x41, 0x91, 0x42, 0x62, 0x15, 0xad, 0x16, 0xc9, 0x15, 0x8d, 0x95, 0x71, 0x8a, 0xbb, 0x22, 0xd7, 0x47, 0xec, 0xd8, 0x3d, 0xdc, 0x4b},
 		},
-		cookie: []byte{0xe6, 0x14, 0x3a, 0x1b, 0x04, 0xea, 0x9e, 0x7a, 0x14, 0xd6, 0x6c, 0x57, 0xd0, 0x0e, 0x32, 0x85, 0x76, 0x18, 0xde, 0xd8},
+		sessionID: []byte{},
+		cookie:    []byte{0xe6, 0x14, 0x3a, 0x1b, 0x04, 0xea, 0x9e, 0x7a, 0x14, 0xd6, 0x6c, 0x57, 0xd0, 0x0e, 0x32, 0x85, 0x76, 0x18, 0xde, 0xd8},
 		cipherSuites: []cipherSuite{
 			&cipherSuiteTLSEcdheEcdsaWithAes128GcmSha256{},
 		},
@@ -52,7 +53,7 @@ func TestHandshakeMessageClientHello(t *testing.T) {
 			compressionMethods[compressionMethodNull],
 		},
 		extensions: []extension{
-			&extensionSupportedEllipticCurves{ellipticCurves: []namedCurve{namedCurveX25519}},
+			&extensionSupportedEllipticCurves{ellipticCurves: []NamedCurve{NamedCurveX25519}},
 			&extensionConnectionId{connectionId: []byte{0x03, 0x09, 0x04}},
 		},
 	}
@@ -71,3 +72,47 @@ func TestHandshakeMessageClientHello(t *testing.T) {
 		t.Errorf("handshakeMessageClientHello marshal: got %#v, want %#v", raw, rawClientHello)
 	}
 }
+
+func TestHandshakeMessageClientHelloTruncated(t *testing.T) {
+	raw, err := (&handshakeMessageClientHello{
+		version:            protocolVersion1_2,
+		sessionID:          []byte{0x01, 0x02},
+		cookie:             []byte{0x03, 0x04},
+		cipherSuites:       []cipherSuite{&cipherSuiteTLSEcdheEcdsaWithAes128GcmSha256{}},
+		compressionMethods: defaultCompressionMethods,
+		extensions:         []extension{},
+	}).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A server parses ClientHellos before the client proved anything, a
+	// truncated one must be an error
+	for i := 0; i < len(raw); i++ {
+		c := &handshakeMessageClientHello{}
+		if err := c.Unmarshal(raw[:i]); err == nil {
+			t.Errorf("handshakeMessageClientHello unmarshal %d bytes: got no error", i)
+		}
+	}
+}
+
+func TestHandshakeMessageClientHelloMalformed(t *testing.T) {
+	// version, random, empty session ID and empty cookie
+	head := append([]byte{0xfe, 0xfd}, make([]byte, handshakeRandomLength+2)...)
+
+	for _, test := range []struct {
+		name string
+		tail []byte
+	}{
+		{"OddCipherSuitesLength", []byte{0x00, 0x03, 0xc0, 0x2b}},
+		{"CipherSuitesPastEnd", []byte{0x00, 0x04, 0xc0, 0x2b}},
+		{"NoCompressionMethods", []byte{0x00, 0x02, 0xc0, 0x2b}},
+		{"CompressionMethodsPastEnd", []byte{0x00, 0x02, 0xc0, 0x2b, 0x02, 0x00}},
+		{"NoExtensions", []byte{0x00, 0x02, 0xc0, 0x2b, 0x01, 0x00}},
+	} {
+		c := &handshakeMessageClientHello{}
+		if err := c.Unmarshal(append(append([]byte{}, head...), test.tail...)); err == nil {
+			t.Errorf("handshakeMessageClientHello unmarshal %s: got no error", test.name)
+		}
+	}
+}
@@ -1,6 +1,18 @@
 package dtls
 
+import (
+	"encoding/binary"
+)
+
+// Structure supports ECDH, PSK and ECDHE_PSK. The format on the wire
+// depends on the keyExchangeAlgorithm of the negotiated cipherSuite,
+// which must be set before calling Unmarshal.
+// https://tools.ietf.org/html/rfc4279#section-2
+// https://tools.ietf.org/html/rfc5489#section-2
 type handshakeMessageClientKeyExchange struct {
+	keyExchangeAlgorithm keyExchangeAlgorithm
+
+	identity  []byte
 	publicKey []byte
 }
 
@@ -9,14 +21,45 @@ func (h handshakeMessageClientKeyExchange) handshakeType() handshakeType {
 }
 
 func (h *handshakeMessageClientKeyExchange) Marshal() ([]byte, error) {
-	return append([]byte{byte(len(h.publicKey))}, h.publicKey...), nil
+	out := []byte{}
+	if h.keyExchangeAlgorithm.isPSK() {
+		out = append(out, []byte{0x00, 0x00}...)
+		binary.BigEndian.PutUint16(out, uint16(len(h.identity)))
+		out = append(out, h.identity...)
+
+		if h.keyExchangeAlgorithm == keyExchangeAlgorithmPsk {
+			return out, nil
+		}
+	}
+
+	out = append(out, byte(len(h.publicKey)))
+	return append(out, h.publicKey...), nil
 }
 
 func (h *handshakeMessageClientKeyExchange) Unmarshal(data []byte) error {
+	if h.keyExchangeAlgorithm.isPSK() {
+		if len(data) < 2 {
+			return errBufferTooSmall
+		}
+		identityLength := int(binary.BigEndian.Uint16(data))
+		if len(data) < 2+identityLength {
+			return errBufferTooSmall
+		}
+		h.identity = append([]byte{}, data[2:2+identityLength]...)
+		data = data[2+identityLength:]
+
+		if h.keyExchangeAlgorithm == keyExchangeAlgorithmPsk {
+			return nil
+		}
+	}
+
+	if len(data) == 0 {
+		return errBufferTooSmall
+	}
 	publicKeyLength := int(data[0])
 	if len(data) <= publicKeyLength {
 		return errBufferTooSmall
 	}
-	h.publicKey = append([]byte{}, data[1:]...)
+	h.publicKey = append([]byte{}, data[1:1+publicKeyLength]...)
 	return nil
 }
@@ -29,3 +29,43 @@ func TestHandshakeMessageClientKeyExchange(t *testing.T) {
 		t.Errorf("handshakeMessageClientKeyExchange marshal: got %#v, want %#v", raw, rawClientKeyExchange)
 	}
 }
+
+func TestHandshakeMessageClientKeyExchangePSK(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		raw    []byte
+		parsed *handshakeMessageClientKeyExchange
+	}{
+		{
+			name: "PSK",
+			raw:  []byte{0x00, 0x02, 0x69, 0x64},
+			parsed: &handshakeMessageClientKeyExchange{
+				keyExchangeAlgorithm: keyExchangeAlgorithmPsk,
+				identity:             []byte("id"),
+			},
+		},
+		{
+			name: "ECDHE_PSK",
+			raw:  []byte{0x00, 0x02, 0x69, 0x64, 0x02, 0xab, 0xcd},
+			parsed: &handshakeMessageClientKeyExchange{
+				keyExchangeAlgorithm: keyExchangeAlgorithmEcdhePsk,
+				identity:             []byte("id"),
+				publicKey:            []byte{0xab, 0xcd},
+			},
+		},
+	} {
+		c := &handshakeMessageClientKeyExchange{keyExchangeAlgorithm: test.parsed.keyExchangeAlgorithm}
+		if err := c.Unmarshal(test.raw); err != nil {
+			t.Error(err)
+		} else if !reflect.DeepEqual(c, test.parsed) {
+			t.Errorf("handshakeMessageClientKeyExchange %s unmarshal: got %#v, want %#v", test.name, c, test.parsed)
+		}
+
+		raw, err := c.Marshal()
+		if err != nil {
+			t.Error(err)
+		} else if !reflect.DeepEqual(raw, test.raw) {
+			t.Errorf("handshakeMessageClientKeyExchange %s marshal: got %#v, want %#v", test.name, raw, test.raw)
+		}
+	}
+}
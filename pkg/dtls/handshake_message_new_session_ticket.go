@@ -0,0 +1,43 @@
+package dtls
+
+import (
+	"encoding/binary"
+)
+
+/*
+The server sends the NewSessionTicket right before its ChangeCipherSpec
+when it announced one in the ServerHello. The ticket is opaque to the
+client, which offers it in a later ClientHello to resume the session.
+https://tools.ietf.org/html/rfc5077#section-3.3
+*/
+type handshakeMessageNewSessionTicket struct {
+	ticketLifetimeHint uint32 // seconds, 0 if unspecified
+	ticket             []byte
+}
+
+const handshakeMessageNewSessionTicketHeaderSize = 6
+
+func (h handshakeMessageNewSessionTicket) handshakeType() handshakeType {
+	return handshakeTypeNewSessionTicket
+}
+
+func (h *handshakeMessageNewSessionTicket) Marshal() ([]byte, error) {
+	out := make([]byte, handshakeMessageNewSessionTicketHeaderSize)
+	binary.BigEndian.PutUint32(out, h.ticketLifetimeHint)
+	binary.BigEndian.PutUint16(out[4:], uint16(len(h.ticket)))
+	return append(out, h.ticket...), nil
+}
+
+func (h *handshakeMessageNewSessionTicket) Unmarshal(data []byte) error {
+	if len(data) < handshakeMessageNewSessionTicketHeaderSize {
+		return errBufferTooSmall
+	}
+	h.ticketLifetimeHint = binary.BigEndian.Uint32(data)
+
+	ticketLength := int(binary.BigEndian.Uint16(data[4:]))
+	if len(data) != handshakeMessageNewSessionTicketHeaderSize+ticketLength {
+		return errLengthMismatch
+	}
+	h.ticket = append([]byte{}, data[handshakeMessageNewSessionTicketHeaderSize:]...)
+	return nil
+}
@@ -0,0 +1,36 @@
+package dtls
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHandshakeMessageNewSessionTicket(t *testing.T) {
+	rawNewSessionTicket := []byte{
+		0x00, 0x09, 0x3a, 0x80, // lifetime hint: 7 days
+		0x00, 0x05,
+		0xde, 0xad, 0xbe, 0xef, 0x01,
+	}
+	parsedNewSessionTicket := &handshakeMessageNewSessionTicket{
+		ticketLifetimeHint: 604800,
+		ticket:             []byte{0xde, 0xad, 0xbe, 0xef, 0x01},
+	}
+
+	h := &handshakeMessageNewSessionTicket{}
+	if err := h.Unmarshal(rawNewSessionTicket); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(h, parsedNewSessionTicket) {
+		t.Errorf("handshakeMessageNewSessionTicket unmarshal: got %#v, want %#v", h, parsedNewSessionTicket)
+	}
+
+	raw, err := h.Marshal()
+	if err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(raw, rawNewSessionTicket) {
+		t.Errorf("handshakeMessageNewSessionTicket marshal: got %#v, want %#v", raw, rawNewSessionTicket)
+	}
+
+	if err := h.Unmarshal(rawNewSessionTicket[:8]); err != errLengthMismatch {
+		t.Errorf("handshakeMessageNewSessionTicket unmarshal truncated: got %v, want %v", err, errLengthMismatch)
+	}
+}
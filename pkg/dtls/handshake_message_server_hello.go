@@ -12,8 +12,9 @@ failure alert.
 https://tools.ietf.org/html/rfc5246#section-7.4.1.3
 */
 type handshakeMessageServerHello struct {
-	version protocolVersion
-	random  handshakeRandom
+	version   protocolVersion
+	random    handshakeRandom
+	sessionID []byte
 
 	cipherSuite       cipherSuite
 	compressionMethod *compressionMethod
@@ -31,6 +32,8 @@ func (h *handshakeMessageServerHello) Marshal() ([]byte, error) {
 		return nil, errCipherSuiteUnset
 	} else if h.compressionMethod == nil {
 		return nil, errCompressionmethodUnset
+	} else if len(h.sessionID) > sessionIDLength {
+		return nil, errSessionIDTooLong
 	}
 
 	out := make([]byte, handshakeMessageServerHelloVariableWidthStart)
@@ -43,7 +46,8 @@ func (h *handshakeMessageServerHello) Marshal() ([]byte, error) {
 	}
 	copy(out[2:], rand)
 
-	out = append(out, 0x00) // SessionID
+	out = append(out, byte(len(h.sessionID)))
+	out = append(out, h.sessionID...)
 
 	out = append(out, []byte{0x00, 0x00}...)
 	binary.BigEndian.PutUint16(out[len(out)-2:], uint16(h.cipherSuite.ID()))
@@ -59,6 +63,10 @@ func (h *handshakeMessageServerHello) Marshal() ([]byte, error) {
 }
 
 func (h *handshakeMessageServerHello) Unmarshal(data []byte) error {
+	if len(data) < 2+handshakeRandomLength {
+		return errBufferTooSmall
+	}
+
 	h.version.major = data[0]
 	h.version.minor = data[1]
 
@@ -67,15 +75,26 @@ func (h *handshakeMessageServerHello) Unmarshal(data []byte) error {
 	}
 
 	currOffset := handshakeMessageServerHelloVariableWidthStart
-	currOffset += int(data[currOffset]) + 1 // SessionID
+	if len(data) <= currOffset || len(data) <= currOffset+int(data[currOffset]) {
+		return errBufferTooSmall
+	}
+	currOffset++
+	h.sessionID = append([]byte{}, data[currOffset:currOffset+int(data[currOffset-1])]...)
+	currOffset += len(h.sessionID)
 
-	if c := cipherSuiteForID(cipherSuiteID(binary.BigEndian.Uint16(data[currOffset:]))); c != nil {
+	if len(data) < currOffset+2 {
+		return errBufferTooSmall
+	}
+	if c := cipherSuiteForID(CipherSuiteID(binary.BigEndian.Uint16(data[currOffset:]))); c != nil {
 		h.cipherSuite = c
 		currOffset += 2
 	} else {
 		return errInvalidCipherSuite
 	}
 
+	if len(data) <= currOffset {
+		return errBufferTooSmall
+	}
 	if compressionMethod, ok := compressionMethods[compressionMethodID(data[currOffset])]; ok {
 		h.compressionMethod = compressionMethod
 		currOffset++
@@ -4,10 +4,18 @@ import (
 	"encoding/binary"
 )
 
-// Structure only supports ECDH
+// Structure supports ECDH, PSK and ECDHE_PSK. The format on the wire
+// depends on the keyExchangeAlgorithm of the negotiated cipherSuite,
+// which must be set before calling Unmarshal.
+// https://tools.ietf.org/html/rfc4279#section-2
+// https://tools.ietf.org/html/rfc5489#section-2
 type handshakeMessageServerKeyExchange struct {
+	keyExchangeAlgorithm keyExchangeAlgorithm
+
+	identityHint []byte
+
 	ellipticCurveType  ellipticCurveType
-	namedCurve         namedCurve
+	namedCurve         NamedCurve
 	publicKey          []byte
 	hashAlgorithm      HashAlgorithm
 	signatureAlgorithm signatureAlgorithm
@@ -19,12 +27,28 @@ func (h handshakeMessageServerKeyExchange) handshakeType() handshakeType {
 }
 
 func (h *handshakeMessageServerKeyExchange) Marshal() ([]byte, error) {
-	out := []byte{byte(h.ellipticCurveType), 0x00, 0x00}
-	binary.BigEndian.PutUint16(out[1:], uint16(h.namedCurve))
+	out := []byte{}
+	if h.keyExchangeAlgorithm.isPSK() {
+		out = append(out, []byte{0x00, 0x00}...)
+		binary.BigEndian.PutUint16(out, uint16(len(h.identityHint)))
+		out = append(out, h.identityHint...)
+
+		if h.keyExchangeAlgorithm == keyExchangeAlgorithmPsk {
+			return out, nil
+		}
+	}
+
+	out = append(out, []byte{byte(h.ellipticCurveType), 0x00, 0x00}...)
+	binary.BigEndian.PutUint16(out[len(out)-2:], uint16(h.namedCurve))
 
 	out = append(out, byte(len(h.publicKey)))
 	out = append(out, h.publicKey...)
 
+	// ECDHE_PSK parameters are authenticated by the PSK, not signed
+	if h.keyExchangeAlgorithm == keyExchangeAlgorithmEcdhePsk {
+		return out, nil
+	}
+
 	out = append(out, []byte{byte(h.hashAlgorithm), byte(h.signatureAlgorithm), 0x00, 0x00}...)
 
 	binary.BigEndian.PutUint16(out[len(out)-2:], uint16(len(h.signature)))
@@ -34,24 +58,50 @@ func (h *handshakeMessageServerKeyExchange) Marshal() ([]byte, error) {
 }
 
 func (h *handshakeMessageServerKeyExchange) Unmarshal(data []byte) error {
+	if h.keyExchangeAlgorithm.isPSK() {
+		if len(data) < 2 {
+			return errBufferTooSmall
+		}
+		identityHintLength := int(binary.BigEndian.Uint16(data))
+		if len(data) < 2+identityHintLength {
+			return errBufferTooSmall
+		}
+		h.identityHint = append([]byte{}, data[2:2+identityHintLength]...)
+		data = data[2+identityHintLength:]
+
+		if h.keyExchangeAlgorithm == keyExchangeAlgorithmPsk {
+			return nil
+		}
+	}
+
+	if len(data) < 4 {
+		return errBufferTooSmall
+	}
+
 	if _, ok := ellipticCurveTypes[ellipticCurveType(data[0])]; ok {
 		h.ellipticCurveType = ellipticCurveType(data[0])
 	} else {
 		return errInvalidEllipticCurveType
 	}
 
-	h.namedCurve = namedCurve(binary.BigEndian.Uint16(data[1:]))
+	h.namedCurve = NamedCurve(binary.BigEndian.Uint16(data[1:]))
 	if _, ok := namedCurves[h.namedCurve]; !ok {
 		return errInvalidNamedCurve
 	}
 
 	publicKeyLength := int(data[3])
 	offset := 4 + publicKeyLength
-	if len(data) <= publicKeyLength {
+	if len(data) < offset {
 		return errBufferTooSmall
 	}
 	h.publicKey = append([]byte{}, data[4:offset]...)
 
+	if h.keyExchangeAlgorithm == keyExchangeAlgorithmEcdhePsk {
+		return nil
+	} else if len(data) <= offset+3 {
+		return errBufferTooSmall
+	}
+
 	h.hashAlgorithm = HashAlgorithm(data[offset])
 	if _, ok := hashAlgorithms[h.hashAlgorithm]; !ok {
 		return errInvalidHashAlgorithm
@@ -66,6 +116,9 @@ func (h *handshakeMessageServerKeyExchange) Unmarshal(data []byte) error {
 
 	signatureLength := int(binary.BigEndian.Uint16(data[offset:]))
 	offset += 2
+	if len(data) < offset+signatureLength {
+		return errBufferTooSmall
+	}
 	h.signature = append([]byte{}, data[offset:offset+signatureLength]...)
 	return nil
 }
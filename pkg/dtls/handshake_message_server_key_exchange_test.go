@@ -19,7 +19,7 @@ func TestHandshakeMessageServerKeyExchange(t *testing.T) {
 	}
 	parsedServerKeyExchange := &handshakeMessageServerKeyExchange{
 		ellipticCurveType:  ellipticCurveTypeNamedCurve,
-		namedCurve:         namedCurveX25519,
+		namedCurve:         NamedCurveX25519,
 		publicKey:          rawServerKeyExchange[4:69],
 		hashAlgorithm:      HashAlgorithmSHA1,
 		signatureAlgorithm: signatureAlgorithmECDSA,
@@ -40,3 +40,45 @@ func TestHandshakeMessageServerKeyExchange(t *testing.T) {
 		t.Errorf("handshakeMessageServerKeyExchange marshal: got %#v, want %#v", raw, rawServerKeyExchange)
 	}
 }
+
+func TestHandshakeMessageServerKeyExchangePSK(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		raw    []byte
+		parsed *handshakeMessageServerKeyExchange
+	}{
+		{
+			name: "PSK",
+			raw:  []byte{0x00, 0x04, 0x68, 0x69, 0x6e, 0x74},
+			parsed: &handshakeMessageServerKeyExchange{
+				keyExchangeAlgorithm: keyExchangeAlgorithmPsk,
+				identityHint:         []byte("hint"),
+			},
+		},
+		{
+			name: "ECDHE_PSK",
+			raw:  []byte{0x00, 0x00, 0x03, 0x00, 0x1d, 0x02, 0xab, 0xcd},
+			parsed: &handshakeMessageServerKeyExchange{
+				keyExchangeAlgorithm: keyExchangeAlgorithmEcdhePsk,
+				identityHint:         []byte{},
+				ellipticCurveType:    ellipticCurveTypeNamedCurve,
+				namedCurve:           NamedCurveX25519,
+				publicKey:            []byte{0xab, 0xcd},
+			},
+		},
+	} {
+		c := &handshakeMessageServerKeyExchange{keyExchangeAlgorithm: test.parsed.keyExchangeAlgorithm}
+		if err := c.Unmarshal(test.raw); err != nil {
+			t.Error(err)
+		} else if !reflect.DeepEqual(c, test.parsed) {
+			t.Errorf("handshakeMessageServerKeyExchange %s unmarshal: got %#v, want %#v", test.name, c, test.parsed)
+		}
+
+		raw, err := c.Marshal()
+		if err != nil {
+			t.Error(err)
+		} else if !reflect.DeepEqual(raw, test.raw) {
+			t.Errorf("handshakeMessageServerKeyExchange %s marshal: got %#v, want %#v", test.name, raw, test.raw)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+// Package ccm implements a CCM, Counter with CBC-MAC
+// as per RFC 3610.
+//
+// See https://tools.ietf.org/html/rfc3610
+//
+// Only the parameters needed by the DTLS CCM cipher suites
+// (RFC 6655) are exercised, but any valid nonce and tag size
+// is accepted.
+package ccm
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+var (
+	errInvalidBlockSize = errors.New("ccm: NewCCM requires 128-bit block cipher")
+	errInvalidTagSize   = errors.New("ccm: tagsize must be 4, 6, 8, 10, 12, 14, or 16")
+	errInvalidNonceSize = errors.New("ccm: invalid nonce size")
+	errOpen             = errors.New("ccm: message authentication failed")
+	errCiphertextSize   = errors.New("ccm: ciphertext too short")
+)
+
+type ccm struct {
+	b cipher.Block
+	M uint8 // tag size
+	L uint8 // size of the length field
+}
+
+// NewCCM returns the given 128-bit block cipher wrapped in CCM.
+// The tagsize must be an even integer between 4 and 16 inclusive
+// and is used as CCM's M parameter.
+// The noncesize must be an integer between 7 and 13 inclusive,
+// 15-noncesize is used as CCM's L parameter.
+func NewCCM(b cipher.Block, tagsize, noncesize int) (cipher.AEAD, error) {
+	if b.BlockSize() != 16 {
+		return nil, errInvalidBlockSize
+	}
+	if tagsize < 4 || tagsize > 16 || tagsize&1 != 0 {
+		return nil, errInvalidTagSize
+	}
+	if noncesize < 7 || noncesize > 13 {
+		return nil, errInvalidNonceSize
+	}
+
+	return &ccm{b: b, M: uint8(tagsize), L: uint8(15 - noncesize)}, nil
+}
+
+func (c *ccm) NonceSize() int { return 15 - int(c.L) }
+func (c *ccm) Overhead() int  { return int(c.M) }
+
+func (c *ccm) maxLength() uint64 {
+	if c.L >= 8 {
+		return math.MaxUint64
+	}
+	return (1 << (8 * c.L)) - 1
+}
+
+// https://tools.ietf.org/html/rfc3610#section-2.2
+func (c *ccm) cbcMAC(nonce, plaintext, additionalData []byte) []byte {
+	var b0 [16]byte
+	flags := ((c.M - 2) / 2) << 3
+	flags |= c.L - 1
+	if len(additionalData) > 0 {
+		flags |= 1 << 6
+	}
+	b0[0] = flags
+	copy(b0[1:], nonce)
+	var lm [8]byte
+	binary.BigEndian.PutUint64(lm[:], uint64(len(plaintext)))
+	copy(b0[16-c.L:], lm[8-c.L:])
+
+	mac := make([]byte, 16)
+	c.b.Encrypt(mac, b0[:])
+
+	block := func(in []byte) {
+		var blk [16]byte
+		copy(blk[:], in)
+		for i := range mac {
+			mac[i] ^= blk[i]
+		}
+		c.b.Encrypt(mac, mac)
+	}
+
+	if len(additionalData) > 0 {
+		var aHeader []byte
+		switch n := uint64(len(additionalData)); {
+		case n < 0xff00:
+			aHeader = make([]byte, 2)
+			binary.BigEndian.PutUint16(aHeader, uint16(n))
+		case n <= math.MaxUint32:
+			aHeader = make([]byte, 6)
+			aHeader[0], aHeader[1] = 0xff, 0xfe
+			binary.BigEndian.PutUint32(aHeader[2:], uint32(n))
+		default:
+			aHeader = make([]byte, 10)
+			aHeader[0], aHeader[1] = 0xff, 0xff
+			binary.BigEndian.PutUint64(aHeader[2:], n)
+		}
+
+		a := append(aHeader, additionalData...)
+		for len(a) > 0 {
+			n := 16
+			if len(a) < n {
+				n = len(a)
+			}
+			block(a[:n])
+			a = a[n:]
+		}
+	}
+
+	for p := plaintext; len(p) > 0; {
+		n := 16
+		if len(p) < n {
+			n = len(p)
+		}
+		block(p[:n])
+		p = p[n:]
+	}
+
+	return mac
+}
+
+// https://tools.ietf.org/html/rfc3610#section-2.3
+func (c *ccm) ctr(nonce []byte, counter uint64, dst, src []byte) {
+	var a, s [16]byte
+	a[0] = c.L - 1
+	copy(a[1:], nonce)
+
+	for len(src) > 0 {
+		var ctr [8]byte
+		binary.BigEndian.PutUint64(ctr[:], counter)
+		copy(a[16-c.L:], ctr[8-c.L:])
+		c.b.Encrypt(s[:], a[:])
+
+		n := 16
+		if len(src) < n {
+			n = len(src)
+		}
+		for i := 0; i < n; i++ {
+			dst[i] = src[i] ^ s[i]
+		}
+		dst, src = dst[n:], src[n:]
+		counter++
+	}
+}
+
+func (c *ccm) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != c.NonceSize() {
+		panic("ccm: incorrect nonce length given to CCM") // nolint
+	} else if uint64(len(plaintext)) > c.maxLength() {
+		panic("ccm: plaintext too large") // nolint
+	}
+
+	ret, out := sliceForAppend(dst, len(plaintext)+int(c.M))
+
+	tag := c.cbcMAC(nonce, plaintext, additionalData)
+	c.ctr(nonce, 0, tag, tag)
+	c.ctr(nonce, 1, out, plaintext)
+	copy(out[len(plaintext):], tag[:c.M])
+
+	return ret
+}
+
+func (c *ccm) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != c.NonceSize() {
+		return nil, errInvalidNonceSize
+	} else if len(ciphertext) < int(c.M) {
+		return nil, errCiphertextSize
+	} else if uint64(len(ciphertext)-int(c.M)) > c.maxLength() {
+		return nil, errCiphertextSize
+	}
+
+	tag := append([]byte{}, ciphertext[len(ciphertext)-int(c.M):]...)
+	ciphertext = ciphertext[:len(ciphertext)-int(c.M)]
+
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	c.ctr(nonce, 1, out, ciphertext)
+
+	expectedTag := c.cbcMAC(nonce, out, additionalData)
+	c.ctr(nonce, 0, expectedTag, expectedTag)
+
+	if subtle.ConstantTimeCompare(expectedTag[:c.M], tag) != 1 {
+		for i := range out {
+			out[i] = 0
+		}
+		return nil, errOpen
+	}
+
+	return ret, nil
+}
+
+// sliceForAppend takes a slice and a requested number of bytes. It returns a
+// slice with the contents of the given slice followed by that many bytes and a
+// second slice that aliases into it and contains only the extra bytes. If the
+// original slice has sufficient capacity then no allocation is performed.
+//
+// https://github.com/golang/go/blob/master/src/crypto/cipher/gcm.go
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return
+}
@@ -0,0 +1,76 @@
+package ccm
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"testing"
+)
+
+func mustHexDecode(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// https://tools.ietf.org/html/rfc3610#section-8
+func TestRFC3610Vectors(t *testing.T) {
+	testCases := []struct {
+		name                    string
+		key, nonce, aad, pt, ct string
+		tagSize                 int
+	}{
+		{
+			name:    "Packet Vector #1",
+			key:     "c0c1c2c3c4c5c6c7c8c9cacbcccdcecf",
+			nonce:   "00000003020100a0a1a2a3a4a5",
+			aad:     "0001020304050607",
+			pt:      "08090a0b0c0d0e0f101112131415161718191a1b1c1d1e",
+			ct:      "588c979a61c663d2f066d0c2c0f989806d5f6b61dac38417e8d12cfdf926e0",
+			tagSize: 8,
+		},
+		{
+			name:    "Packet Vector #24",
+			key:     "d7828d13b2b0bdc325a76236df93cc6b",
+			nonce:   "008d493b30ae8b3c9696766cfa",
+			aad:     "6e37a6ef546d955d34ab6059",
+			pt:      "abf21c0b02feb88f856df4a37381bce3cc128517d4",
+			ct:      "f32905b88a641b04b9c9ffb58cc390900f3da12ab16dce9e82efa16da62059",
+			tagSize: 10,
+		},
+	}
+
+	for _, testCase := range testCases {
+		block, err := aes.NewCipher(mustHexDecode(t, testCase.key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonce := mustHexDecode(t, testCase.nonce)
+		c, err := NewCCM(block, testCase.tagSize, len(nonce))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		aad := mustHexDecode(t, testCase.aad)
+		pt := mustHexDecode(t, testCase.pt)
+		ct := mustHexDecode(t, testCase.ct)
+
+		if actual := c.Seal(nil, nonce, pt, aad); !bytes.Equal(actual, ct) {
+			t.Errorf("%s seal: got %x, want %x", testCase.name, actual, ct)
+		}
+
+		actual, err := c.Open(nil, nonce, ct, aad)
+		if err != nil {
+			t.Errorf("%s open: %v", testCase.name, err)
+		} else if !bytes.Equal(actual, pt) {
+			t.Errorf("%s open: got %x, want %x", testCase.name, actual, pt)
+		}
+
+		ct[0] ^= 0x01
+		if _, err := c.Open(nil, nonce, ct, aad); err != errOpen {
+			t.Errorf("%s open tampered: expected '%v' actual '%v'", testCase.name, errOpen, err)
+		}
+	}
+}
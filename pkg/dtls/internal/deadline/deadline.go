@@ -0,0 +1,90 @@
+// Package deadline implements the deadlines of net.Conn for connections
+// that don't sit directly on a socket
+package deadline
+
+import (
+	"sync"
+	"time"
+)
+
+// ErrTimeout is returned by operations whose deadline passed, it
+// satisfies net.Error
+var ErrTimeout error = timeoutError{}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// Deadline is a point in time after which Done is closed. It can be moved
+// at any time, like the deadlines of a net.Conn.
+type Deadline struct {
+	lock  sync.Mutex
+	timer *time.Timer
+	done  chan struct{}
+}
+
+// New returns a Deadline that is not set
+func New() *Deadline {
+	return &Deadline{done: make(chan struct{})}
+}
+
+// Set moves the deadline to t, the zero time means no deadline. Done is
+// reopened if the deadline moves to the future.
+func (d *Deadline) Set(t time.Time) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+
+	select {
+	case <-d.done:
+		d.done = make(chan struct{})
+	default:
+	}
+
+	if t.IsZero() {
+		return
+	}
+
+	done := d.done
+	wait := time.Until(t)
+	if wait <= 0 {
+		close(done)
+		return
+	}
+	d.timer = time.AfterFunc(wait, func() {
+		d.lock.Lock()
+		defer d.lock.Unlock()
+
+		// The deadline may have been moved while the timer fired
+		if d.done == done {
+			select {
+			case <-done:
+			default:
+				close(done)
+			}
+		}
+	})
+}
+
+// Done returns a channel that is closed when the deadline passes
+func (d *Deadline) Done() <-chan struct{} {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.done
+}
+
+// Exceeded reports whether the deadline passed
+func (d *Deadline) Exceeded() bool {
+	select {
+	case <-d.Done():
+		return true
+	default:
+		return false
+	}
+}
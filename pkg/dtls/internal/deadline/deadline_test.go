@@ -0,0 +1,47 @@
+package deadline
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestDeadline(t *testing.T) {
+	d := New()
+	if d.Exceeded() {
+		t.Fatal("Deadline exceeded before it was set")
+	}
+
+	d.Set(time.Now().Add(20 * time.Millisecond))
+	if d.Exceeded() {
+		t.Fatal("Deadline exceeded early")
+	}
+	select {
+	case <-d.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Deadline not exceeded in time")
+	}
+
+	// Moving the deadline to the future reopens it
+	d.Set(time.Now().Add(time.Hour))
+	if d.Exceeded() {
+		t.Fatal("Deadline exceeded after it was moved to the future")
+	}
+
+	d.Set(time.Now().Add(-time.Second))
+	if !d.Exceeded() {
+		t.Fatal("Deadline in the past not exceeded")
+	}
+
+	// A moved deadline doesn't fire at the old time
+	d.Set(time.Now().Add(10 * time.Millisecond))
+	d.Set(time.Time{})
+	time.Sleep(30 * time.Millisecond)
+	if d.Exceeded() {
+		t.Fatal("cleared Deadline exceeded")
+	}
+
+	if err, ok := ErrTimeout.(net.Error); !ok || !err.Timeout() {
+		t.Fatalf("ErrTimeout is not a net.Error timeout: %#v", ErrTimeout)
+	}
+}
@@ -2,41 +2,112 @@ package udp
 
 import (
 	"errors"
-	"fmt"
 	"io"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/thomas-fossati/dtls/pkg/dtls/internal/deadline"
+	"github.com/thomas-fossati/dtls/pkg/logging"
 )
 
-const receiveMTU = 8192
+// receiveMTU holds the largest UDP datagram, a DTLS record may carry up
+// to 16KB of data
+const receiveMTU = 65535
+
+// acceptBacklog is the number of new Conns that wait for Accept, the
+// packets of further new remotes are dropped
+const acceptBacklog = 128
+
+// receiveQueueSize is the number of packets a Conn holds until they are
+// read, further packets are dropped
+const receiveQueueSize = 64
+
+// bufferPool holds the packets queued in the Conns, sized for a typical
+// MTU. Larger packets grow their buffer, which then goes back to the pool.
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 1500)
+		return &b
+	},
+}
+
+// newBuffer returns a pooled copy of pkt
+func newBuffer(pkt []byte) *[]byte {
+	b := bufferPool.Get().(*[]byte)
+	*b = append((*b)[:0], pkt...)
+	return b
+}
 
 var (
 	errClosedListener          = errors.New("udp: listener closed")
 	errRecordTooShort          = errors.New("udp: DTLS record too short to carry a CID")
 	errUnknownCid              = errors.New("udp: DTLS record carries a CID that is not registered")
 	errNoListenerForConnection = errors.New("udp: no listener associated with connection")
+	errRejected                = errors.New("udp: packet rejected by the accept filter")
+	errBacklogFull             = errors.New("udp: accept backlog full")
 )
 
-// Listener augments a connection-oriented Listener over a UDP PacketConn
+// AcceptFilter is called with the first packet received from an unknown
+// remote, a Conn is only created for the remote if it returns true
+type AcceptFilter func(raddr net.Addr, pkt []byte) bool
+
+// ListenerConfig holds the settings a Listener applies from the first
+// packet it reads
+type ListenerConfig struct {
+	// CidLen is the size in bytes of the connection id used when receiving
+	CidLen int
+
+	// AcceptFilter is passed the first packet of an unknown remote, a Conn
+	// is only created for the remote if it returns true
+	AcceptFilter AcceptFilter
+
+	// LoggerFactory sets where the Listener and its Conns log to, they are
+	// silent if it is nil
+	LoggerFactory logging.LoggerFactory
+}
+
+// Listener augments a connection-oriented Listener over a PacketConn
 type Listener struct {
-	pConn *net.UDPConn
+	queueDrops uint64 // accessed atomically, first for 64-bit alignment
+
+	pConn  net.PacketConn
+	cidLen int
+	filter AcceptFilter
+	log    logging.LeveledLogger
 
 	lock      sync.RWMutex
 	accepting bool
 	acceptCh  chan *Conn
 	doneCh    chan struct{}
 	doneOnce  sync.Once
-	cidLen    int
 
 	conns    map[string]*Conn // maps receiver's 2-tuple into Conn
 	cidConns map[string]*Conn // maps CIDs into Conn
+
+	unknownCidDrops uint64 // guarded by lock
+	migrations      uint64 // guarded by lock
 }
 
-// SetCidLen sets the size in bytes of the connection id used when
-// receiving
-func (l *Listener) SetCidLen(v int) {
-	l.cidLen = v
+// Stats holds the counters of a Listener
+type Stats struct {
+	ActiveConns     uint64 // remotes with an open Conn
+	UnknownCidDrops uint64 // records dropped for carrying an unknown CID
+	Migrations      uint64 // CIDs received from a new remote address
+	QueueDrops      uint64 // packets dropped because a Conn's queue was full
+}
+
+// Stats returns the counters of the Listener
+func (l *Listener) Stats() Stats {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return Stats{
+		ActiveConns:     uint64(len(l.conns)),
+		UnknownCidDrops: l.unknownCidDrops,
+		Migrations:      l.migrations,
+		QueueDrops:      atomic.LoadUint64(&l.queueDrops),
+	}
 }
 
 func (l *Listener) MoveConnToCidConns(conn *Conn, cid []byte) {
@@ -44,6 +115,7 @@ func (l *Listener) MoveConnToCidConns(conn *Conn, cid []byte) {
 	defer l.lock.Unlock()
 	// TODO(tho) delete conn from conns map
 	l.cidConns[string(cid)] = conn
+	conn.cid = cid
 }
 
 // Accept waits for and returns the next connection to the listener.
@@ -60,18 +132,28 @@ func (l *Listener) Accept() (*Conn, error) {
 
 // Close closes the listener.
 // Any blocked Accept operations will be unblocked and return errors.
+// The Conns that were not accepted yet are closed.
 func (l *Listener) Close() error {
 	l.lock.Lock()
-	defer l.lock.Unlock()
-
 	var err error
 	l.doneOnce.Do(func() {
 		l.accepting = false
 		close(l.doneCh)
 		err = l.cleanup()
 	})
+	l.lock.Unlock()
 
-	return err
+	// No Conn is queued once accepting is false
+	for {
+		select {
+		case c := <-l.acceptCh:
+			if cerr := c.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+		default:
+			return err
+		}
+	}
 }
 
 // cleanup closes the packet conn if it is no longer used
@@ -94,23 +176,36 @@ func Listen(network string, laddr *net.UDPAddr) (*Listener, error) {
 	if err != nil {
 		return nil, err
 	}
+	return NewListener(conn, nil), nil
+}
+
+// NewListener creates a new listener over pConn, it then owns pConn and
+// closes it once the listener and all its Conns are closed. config may be
+// nil.
+func NewListener(pConn net.PacketConn, config *ListenerConfig) *Listener {
+	if config == nil {
+		config = &ListenerConfig{}
+	}
 
 	l := &Listener{
-		pConn:     conn,
-		acceptCh:  make(chan *Conn),
+		pConn:     pConn,
+		acceptCh:  make(chan *Conn, acceptBacklog),
 		conns:     make(map[string]*Conn),
 		cidConns:  make(map[string]*Conn),
 		accepting: true,
 		doneCh:    make(chan struct{}),
+		cidLen:    config.CidLen,
+		filter:    config.AcceptFilter,
+		log:       logging.NewLogger(config.LoggerFactory, "udp"),
 	}
 
 	go l.readLoop()
 
-	return l, nil
+	return l
 }
 
 // maybeExtractCid tries to grab the CID from the records header
-func (l Listener) maybeExtractCid(pkt []byte) ([]byte, error) {
+func (l *Listener) maybeExtractCid(pkt []byte) ([]byte, error) {
 	if pkt[0] == 0x19 {
 		if len(pkt[11:]) < l.cidLen+2 {
 			return nil, errRecordTooShort
@@ -119,8 +214,6 @@ func (l Listener) maybeExtractCid(pkt []byte) ([]byte, error) {
 		cid := make([]byte, l.cidLen)
 		copy(cid, pkt[11:11+l.cidLen])
 
-		fmt.Printf("[Listener::maybeExtractCid] got cid % x\n", cid)
-
 		return cid, nil
 	}
 
@@ -131,10 +224,11 @@ func (l Listener) maybeExtractCid(pkt []byte) ([]byte, error) {
 // 1. Dispatching incoming packets to the correct Conn.
 //    It can therefore not be ended until all Conns are closed.
 // 2. Creating a new Conn when receiving from a new remote.
+// It never waits for a Conn, so that a Conn that isn't read doesn't hold
+// up the others.
 func (l *Listener) readLoop() {
 	buf := make([]byte, receiveMTU)
 
-readLoop:
 	for {
 		n, raddr, err := l.pConn.ReadFrom(buf)
 		if err != nil {
@@ -147,23 +241,20 @@ readLoop:
 			continue
 		}
 
-		conn, err := l.getConn(raddr, cid)
+		conn, err := l.getConn(raddr, cid, buf[:n])
 		if err != nil {
 			continue
 		}
-		select {
-		case cBuf := <-conn.readCh:
-			n = copy(cBuf, buf[:n])
-			conn.sizeCh <- n
-		case <-conn.doneCh:
-			continue readLoop
+		if !conn.enqueue(buf[:n]) {
+			atomic.AddUint64(&l.queueDrops, 1)
+			l.log.Tracef("%s: receive queue full, dropping packet", raddr)
 		}
 	}
 }
 
 // TODO "promote connection": l.cidConns[string(cid)] = conn
 
-func (l *Listener) getConn(raddr net.Addr, cid []byte) (*Conn, error) {
+func (l *Listener) getConn(raddr net.Addr, cid []byte, pkt []byte) (*Conn, error) {
 	l.lock.Lock()
 	defer l.lock.Unlock()
 
@@ -182,59 +273,107 @@ func (l *Listener) getConn(raddr net.Addr, cid []byte) (*Conn, error) {
 		// analogous of a "connected" UDP socket receiving a datagram
 		// on an unknown 4-tuple.
 		if !ok {
-			fmt.Printf("no connection found for cid % x\n", cid)
+			l.unknownCidDrops++
+			l.log.Debugf("%s: no connection found for cid %x, dropping packet", raddr, cid)
 			return nil, errUnknownCid
 		}
-		// force update the peer's 2-tuple (in case it changed because
-		// of NAT rebind or connection migration)
-		conn.SetRemoteAddr(raddr)
+		l.log.Tracef("%s: record carries cid %x", raddr, cid)
+		if prev := conn.RemoteAddr(); prev.String() != raddr.String() {
+			l.migrations++
+			l.log.Debugf("%s: cid %x moved from %s", raddr, cid, prev)
+			// update the peer's 2-tuple (it changed because of NAT
+			// rebind or connection migration)
+			conn.SetRemoteAddr(raddr)
+		}
 	} else {
 		conn, ok = l.conns[raddr.String()]
 		if !ok {
 			if !l.accepting {
 				return nil, errClosedListener
+			} else if l.filter != nil && !l.filter(raddr, pkt) {
+				l.log.Tracef("%s: packet rejected by the accept filter", raddr)
+				return nil, errRejected
 			}
+			// The read loop must not wait for Accept, it serves the
+			// other remotes too
 			conn = l.newConn(raddr, cid)
+			select {
+			case l.acceptCh <- conn:
+			default:
+				l.log.Debugf("%s: accept backlog full, dropping packet", raddr)
+				return nil, errBacklogFull
+			}
+			l.log.Debugf("%s: new connection", raddr)
 			l.conns[raddr.String()] = conn
-			l.acceptCh <- conn
 		}
 	}
 
 	return conn, nil
 }
 
-// Conn augments a connection-oriented connection over a UDP PacketConn
+// Conn augments a connection-oriented connection over a PacketConn
 type Conn struct {
 	listener *Listener
 
-	rAddr net.Addr
-	cid   []byte
+	rAddrLock sync.RWMutex // rAddr moves with the peer
+	rAddr     net.Addr
+	connsKey  string // the remote the Conn was created for, its key in conns
+	cid       []byte
 
-	readCh chan []byte
-	sizeCh chan int
+	readCh chan *[]byte // pooled packets
 
 	lock     sync.RWMutex
 	doneCh   chan struct{}
 	doneOnce sync.Once
+
+	readDeadline  *deadline.Deadline
+	writeDeadline *deadline.Deadline
 }
 
 func (l *Listener) newConn(rAddr net.Addr, cid []byte) *Conn {
 	return &Conn{
 		listener: l,
 		rAddr:    rAddr,
+		connsKey: rAddr.String(),
 		cid:      cid,
-		readCh:   make(chan []byte),
-		sizeCh:   make(chan int),
+		readCh:   make(chan *[]byte, receiveQueueSize),
 		doneCh:   make(chan struct{}),
+
+		readDeadline:  deadline.New(),
+		writeDeadline: deadline.New(),
 	}
 }
 
-// Read
+// enqueue queues a copy of pkt for Read, it returns false if the queue is
+// full and pkt is dropped. Packets for a closed Conn are dropped silently.
+func (c *Conn) enqueue(pkt []byte) bool {
+	select {
+	case <-c.doneCh:
+		return true
+	default:
+	}
+
+	b := newBuffer(pkt)
+	select {
+	case c.readCh <- b:
+		return true
+	default:
+		bufferPool.Put(b)
+		return false
+	}
+}
+
+// Read reads the next packet received from the remote, it fails with a
+// timeout once the read deadline passed. A packet longer than p is
+// truncated.
 func (c *Conn) Read(p []byte) (int, error) {
 	select {
-	case c.readCh <- p:
-		n := <-c.sizeCh
+	case b := <-c.readCh:
+		n := copy(p, *b)
+		bufferPool.Put(b)
 		return n, nil
+	case <-c.readDeadline.Done():
+		return 0, deadline.ErrTimeout
 	case <-c.doneCh:
 		return 0, io.EOF
 	}
@@ -248,9 +387,11 @@ func (c *Conn) Write(p []byte) (n int, err error) {
 
 	if l == nil {
 		return 0, io.EOF
+	} else if c.writeDeadline.Exceeded() {
+		return 0, deadline.ErrTimeout
 	}
 
-	return l.pConn.WriteTo(p, c.rAddr)
+	return l.pConn.WriteTo(p, c.RemoteAddr())
 }
 
 // Close closes the conn and releases any Read calls
@@ -262,7 +403,12 @@ func (c *Conn) Close() error {
 	c.doneOnce.Do(func() {
 		close(c.doneCh)
 		c.listener.lock.Lock()
-		delete(c.listener.conns, c.rAddr.String())
+		if c.listener.conns[c.connsKey] == c {
+			delete(c.listener.conns, c.connsKey)
+		}
+		if c.cid != nil && c.listener.cidConns[string(c.cid)] == c {
+			delete(c.listener.cidConns, string(c.cid))
+		}
 		err = c.listener.cleanup()
 		c.listener.lock.Unlock()
 		c.listener = nil
@@ -286,26 +432,37 @@ func (c *Conn) LocalAddr() net.Addr {
 
 // RemoteAddr is a stub
 func (c *Conn) RemoteAddr() net.Addr {
+	c.rAddrLock.RLock()
+	defer c.rAddrLock.RUnlock()
 	return c.rAddr
 }
 
 // SetRemoteAddr updates the remote address associated with this Conn
 func (c *Conn) SetRemoteAddr(v net.Addr) {
+	c.rAddrLock.Lock()
+	defer c.rAddrLock.Unlock()
 	c.rAddr = v
 }
 
-// SetDeadline is a stub
+// SetDeadline sets the read and write deadlines
 func (c *Conn) SetDeadline(t time.Time) error {
+	c.readDeadline.Set(t)
+	c.writeDeadline.Set(t)
 	return nil
 }
 
-// SetReadDeadline is a stub
+// SetReadDeadline sets the time after which Read fails with a timeout, the
+// zero time means Read doesn't time out
 func (c *Conn) SetReadDeadline(t time.Time) error {
+	c.readDeadline.Set(t)
 	return nil
 }
 
-// SetWriteDeadline is a stub
+// SetWriteDeadline sets the time after which Write fails with a timeout.
+// Writes to UDP don't block, so only the deadline passing before Write is
+// called is detected.
 func (c *Conn) SetWriteDeadline(t time.Time) error {
+	c.writeDeadline.Set(t)
 	return nil
 }
 
@@ -68,6 +68,195 @@ func stressDuplex(t *testing.T) {
 	}
 }
 
+func TestReadDeadline(t *testing.T) {
+	lim := test.TimeOut(time.Second * 5)
+	defer lim.Stop()
+
+	ca, cb, err := pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = ca.Close()
+		_ = cb.Close()
+	}()
+
+	if err = ca.SetReadDeadline(time.Now().Add(50 * time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 16)
+	if _, err = ca.Read(buf); err == nil {
+		t.Fatal("Read returned before the deadline passed")
+	} else if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+		t.Fatalf("Read: got %v, want a timeout", err)
+	}
+
+	// The Conn is still usable once the deadline is cleared
+	if err = ca.SetReadDeadline(time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cb.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	n, err := ca.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	} else if string(buf[:n]) != "ping" {
+		t.Errorf("Read after timeout: got %q, want %q", buf[:n], "ping")
+	}
+}
+
+func TestAcceptFilter(t *testing.T) {
+	lim := test.TimeOut(time.Second * 5)
+	defer lim.Stop()
+
+	network, addr := getConfig()
+	pConn, err := net.ListenUDP(network, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener := NewListener(pConn, &ListenerConfig{
+		AcceptFilter: func(raddr net.Addr, pkt []byte) bool {
+			return string(pkt) == "hello"
+		},
+	})
+	defer func() {
+		_ = listener.Close()
+	}()
+
+	dConn, err := net.DialUDP(network, nil, listener.Addr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = dConn.Close()
+	}()
+
+	// The rejected packet doesn't create a Conn, the next one does
+	for _, msg := range []string{"rejected", "hello"} {
+		if _, err = dConn.Write([]byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lConn, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = lConn.Close()
+	}()
+
+	buf := make([]byte, 16)
+	n, err := lConn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	} else if string(buf[:n]) != "hello" {
+		t.Errorf("Read from accepted Conn: got %q, want %q", buf[:n], "hello")
+	}
+}
+
+func TestReadLoopDoesNotWait(t *testing.T) {
+	lim := test.TimeOut(time.Second * 5)
+	defer lim.Stop()
+
+	network, addr := getConfig()
+	listener, err := Listen(network, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = listener.Close()
+	}()
+
+	dial := func(msg string) *net.UDPConn {
+		dConn, err := net.DialUDP(network, nil, listener.Addr().(*net.UDPAddr))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = dConn.Write([]byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+		return dConn
+	}
+	first := dial("first")
+	defer func() {
+		_ = first.Close()
+	}()
+	lConn, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = lConn.Close()
+	}()
+
+	// The Conn of the second remote is neither accepted nor read, the
+	// packets of the first one are still delivered
+	second := dial("second")
+	defer func() {
+		_ = second.Close()
+	}()
+	if _, err = first.Write([]byte("again")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 16)
+	for _, expected := range []string{"first", "again"} {
+		n, err := lConn.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		} else if string(buf[:n]) != expected {
+			t.Errorf("Read from accepted Conn: got %q, want %q", buf[:n], expected)
+		}
+	}
+}
+
+func TestReceiveQueueFull(t *testing.T) {
+	lim := test.TimeOut(time.Second * 5)
+	defer lim.Stop()
+
+	network, addr := getConfig()
+	listener, err := Listen(network, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = listener.Close()
+	}()
+
+	dConn, err := net.DialUDP(network, nil, listener.Addr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = dConn.Close()
+	}()
+
+	// The packets that don't fit in the queue are dropped
+	for i := 0; i < receiveQueueSize+1; i++ {
+		if _, err = dConn.Write([]byte(fmt.Sprint(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for listener.Stats().QueueDrops != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	lConn, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = lConn.Close()
+	}()
+	buf := make([]byte, 16)
+	n, err := lConn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	} else if string(buf[:n]) != "0" {
+		t.Errorf("Read from accepted Conn: got %q, want %q", buf[:n], "0")
+	}
+}
+
 func pipe() (*Conn, *net.UDPConn, error) {
 	// Start listening
 	network, addr := getConfig()
@@ -139,3 +328,183 @@ func getConfig() (string, *net.UDPAddr) {
 // 		t.Fatalf("Failed to close B side: %v\n", err)
 // 	}
 // }
+
+// memPacket is a datagram of a memPacketConn
+type memPacket struct {
+	data []byte
+	addr net.Addr
+}
+
+// memPacketConn is an in-memory net.PacketConn, the test writes to in and
+// reads from out
+type memPacketConn struct {
+	in, out chan memPacket
+	done    chan struct{}
+}
+
+func (c *memPacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	select {
+	case pkt := <-c.in:
+		return copy(p, pkt.data), pkt.addr, nil
+	case <-c.done:
+		return 0, nil, errClosedListener
+	}
+}
+
+func (c *memPacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	c.out <- memPacket{append([]byte{}, p...), addr}
+	return len(p), nil
+}
+
+func (c *memPacketConn) Close() error {
+	close(c.done)
+	return nil
+}
+
+func (c *memPacketConn) LocalAddr() net.Addr                { return &net.UDPAddr{} }
+func (c *memPacketConn) SetDeadline(t time.Time) error      { return nil }
+func (c *memPacketConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *memPacketConn) SetWriteDeadline(t time.Time) error { return nil }
+
+func TestNewListener(t *testing.T) {
+	lim := test.TimeOut(time.Second * 5)
+	defer lim.Stop()
+
+	pConn := &memPacketConn{
+		in:   make(chan memPacket),
+		out:  make(chan memPacket, 1),
+		done: make(chan struct{}),
+	}
+	listener := NewListener(pConn, nil)
+
+	raddr := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 5684}
+	pConn.in <- memPacket{[]byte("hello"), raddr}
+	lConn, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 16)
+	n, err := lConn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	} else if string(buf[:n]) != "hello" {
+		t.Errorf("Read from accepted Conn: got %q, want %q", buf[:n], "hello")
+	}
+
+	if _, err = lConn.Write([]byte("world")); err != nil {
+		t.Fatal(err)
+	}
+	if pkt := <-pConn.out; string(pkt.data) != "world" || pkt.addr != raddr {
+		t.Errorf("WriteTo: got %q to %v, want %q to %v", pkt.data, pkt.addr, "world", raddr)
+	}
+
+	// The PacketConn is closed with the last Conn
+	if err = listener.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-pConn.done:
+		t.Fatal("PacketConn closed while a Conn is open")
+	default:
+	}
+	if err = lConn.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-pConn.done:
+	default:
+		t.Error("PacketConn not closed with the last Conn")
+	}
+}
+
+func TestStatsAfterMigration(t *testing.T) {
+	lim := test.TimeOut(time.Second * 5)
+	defer lim.Stop()
+
+	pConn := &memPacketConn{
+		in:   make(chan memPacket),
+		out:  make(chan memPacket, 1),
+		done: make(chan struct{}),
+	}
+	listener := NewListener(pConn, &ListenerConfig{CidLen: 4})
+	defer func() {
+		_ = listener.Close()
+	}()
+
+	raddr := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 5684}
+	pConn.in <- memPacket{[]byte("hello"), raddr}
+	lConn, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cid := []byte{1, 2, 3, 4}
+	if err = lConn.PromoteToCidConnection(cid); err != nil {
+		t.Fatal(err)
+	}
+
+	// A record carrying the CID from a new address moves the Conn
+	record := append(append([]byte{0x19}, make([]byte, 10)...), cid...)
+	record = append(record, 0, 0)
+	moved := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 2), Port: 5684}
+	pConn.in <- memPacket{record, moved}
+	for listener.Stats().Migrations != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	if got := lConn.RemoteAddr(); got != moved {
+		t.Errorf("RemoteAddr after migration: got %v, want %v", got, moved)
+	}
+
+	if err = lConn.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := listener.Stats().ActiveConns; got != 0 {
+		t.Errorf("ActiveConns after Close: got %d, want %d", got, 0)
+	}
+}
+
+func TestCloseForgetsCid(t *testing.T) {
+	lim := test.TimeOut(time.Second * 5)
+	defer lim.Stop()
+
+	pConn := &memPacketConn{
+		in:   make(chan memPacket),
+		out:  make(chan memPacket, 1),
+		done: make(chan struct{}),
+	}
+	listener := NewListener(pConn, &ListenerConfig{CidLen: 4})
+	defer func() {
+		_ = listener.Close()
+	}()
+
+	raddr := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 5684}
+	pConn.in <- memPacket{[]byte("hello"), raddr}
+	lConn, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cid := []byte{1, 2, 3, 4}
+	if err = lConn.PromoteToCidConnection(cid); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = lConn.Read(make([]byte, 16)); err != nil {
+		t.Fatal(err)
+	}
+	if err = lConn.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !lConn.enqueue([]byte("late")) || len(lConn.readCh) != 0 {
+		t.Error("enqueue: queued a packet for a closed Conn")
+	}
+
+	// The CID of a closed Conn is unknown
+	record := append(append([]byte{0x19}, make([]byte, 10)...), cid...)
+	record = append(record, 0, 0)
+	pConn.in <- memPacket{record, raddr}
+	for listener.Stats().UnknownCidDrops != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	if got := listener.Stats().QueueDrops; got != 0 {
+		t.Errorf("QueueDrops: got %d, want %d", got, 0)
+	}
+}
@@ -0,0 +1,81 @@
+package dtls
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// jsonTracer writes every event as a line of JSON
+type jsonTracer struct {
+	lock sync.Mutex
+	w    io.Writer
+	now  func() time.Time
+}
+
+// NewJSONTracer returns a Tracer that writes the events to w, one JSON
+// object per line, in the spirit of qlog. Each object holds the time, the
+// local and remote addresses and the name of the event, followed by the
+// fields of the event, e.g.
+//
+//	{"time":"2019-03-01T10:00:00.000000001Z","local":"127.0.0.1:4444","remote":"127.0.0.1:5555","event":"flight_changed","from":3,"to":5}
+//
+// The events are named handshake_message_sent, handshake_message_received,
+// flight_changed, timer_fired and record_dropped. Errors writing to w are
+// ignored.
+func NewJSONTracer(w io.Writer) Tracer {
+	return &jsonTracer{w: w, now: time.Now}
+}
+
+func (t *jsonTracer) HandshakeMessage(c *Conn, e HandshakeMessageEvent) {
+	if e.Sent {
+		t.write(c, "handshake_message_sent", e)
+	} else {
+		t.write(c, "handshake_message_received", e)
+	}
+}
+
+func (t *jsonTracer) FlightChanged(c *Conn, e FlightEvent) {
+	t.write(c, "flight_changed", e)
+}
+
+func (t *jsonTracer) TimerFired(c *Conn, e TimerEvent) {
+	t.write(c, "timer_fired", e)
+}
+
+func (t *jsonTracer) RecordDropped(c *Conn, e RecordDroppedEvent) {
+	t.write(c, "record_dropped", e)
+}
+
+func (t *jsonTracer) write(c *Conn, event string, e interface{}) {
+	fields, err := json.Marshal(e)
+	if err != nil || len(fields) < 2 {
+		return
+	}
+
+	var local, remote string
+	if addr := c.LocalAddr(); addr != nil {
+		local = addr.String()
+	}
+	if addr := c.RemoteAddr(); addr != nil {
+		remote = addr.String()
+	}
+	head, err := json.Marshal(struct {
+		Time   time.Time `json:"time"`
+		Local  string    `json:"local"`
+		Remote string    `json:"remote"`
+		Event  string    `json:"event"`
+	}{t.now().UTC(), local, remote, event})
+	if err != nil {
+		return
+	}
+
+	// Splice the fields of the event into the head object
+	line := fmt.Sprintf("%s,%s\n", head[:len(head)-1], fields[1:])
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	_, _ = io.WriteString(t.w, line)
+}
@@ -0,0 +1,43 @@
+package dtls
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+// addrConn is a NetConnWithCid that only has addresses
+type addrConn struct {
+	NetConnWithCid
+	local, remote net.Addr
+}
+
+func (c addrConn) LocalAddr() net.Addr  { return c.local }
+func (c addrConn) RemoteAddr() net.Addr { return c.remote }
+
+func TestJSONTracer(t *testing.T) {
+	var buf bytes.Buffer
+	tracer := NewJSONTracer(&buf)
+	tracer.(*jsonTracer).now = func() time.Time { return time.Unix(1, 2) }
+	c := &Conn{nextConn: addrConn{
+		local:  &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 4444},
+		remote: &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5555},
+	}}
+
+	tracer.HandshakeMessage(c, HandshakeMessageEvent{Sent: true, Type: "ClientHello", Flight: 1, Length: 100, FragmentLength: 100})
+	tracer.HandshakeMessage(c, HandshakeMessageEvent{Type: "HelloVerifyRequest", Flight: 1, SequenceNumber: 1, Length: 35, FragmentLength: 35})
+	tracer.FlightChanged(c, FlightEvent{From: 1, To: 3})
+	tracer.TimerFired(c, TimerEvent{Flight: 3})
+	tracer.RecordDropped(c, RecordDroppedEvent{ContentType: 23, Epoch: 1, SequenceNumber: 7, Reason: DropReplayDuplicate})
+
+	head := `{"time":"1970-01-01T00:00:01.000000002Z","local":"127.0.0.1:4444","remote":"127.0.0.1:5555",`
+	expected := head + `"event":"handshake_message_sent","sent":true,"type":"ClientHello","flight":1,"epoch":0,"sequence_number":0,"message_sequence":0,"length":100,"fragment_offset":0,"fragment_length":100}` + "\n" +
+		head + `"event":"handshake_message_received","sent":false,"type":"HelloVerifyRequest","flight":1,"epoch":0,"sequence_number":1,"message_sequence":0,"length":35,"fragment_offset":0,"fragment_length":35}` + "\n" +
+		head + `"event":"flight_changed","from":1,"to":3}` + "\n" +
+		head + `"event":"timer_fired","flight":3}` + "\n" +
+		head + `"event":"record_dropped","content_type":23,"epoch":1,"sequence_number":7,"reason":"replay_duplicate"}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("JSONTracer: got\n%s\nwant\n%s", buf.String(), expected)
+	}
+}
@@ -0,0 +1,20 @@
+package dtls
+
+// keyExchangeAlgorithm is the key exchange method of a cipherSuite, it
+// decides which handshake messages are sent and what they carry.
+// https://tools.ietf.org/html/rfc4279#section-2
+type keyExchangeAlgorithm int
+
+const (
+	keyExchangeAlgorithmEcdhe    keyExchangeAlgorithm = iota // ECDHE, authenticated with certificates
+	keyExchangeAlgorithmPsk                                  // PSK only, RFC 4279
+	keyExchangeAlgorithmEcdhePsk                             // ECDHE authenticated with a PSK, RFC 5489
+)
+
+func (k keyExchangeAlgorithm) isPSK() bool {
+	return k == keyExchangeAlgorithmPsk || k == keyExchangeAlgorithmEcdhePsk
+}
+
+func (k keyExchangeAlgorithm) isECDHE() bool {
+	return k == keyExchangeAlgorithmEcdhe || k == keyExchangeAlgorithmEcdhePsk
+}
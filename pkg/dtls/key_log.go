@@ -0,0 +1,27 @@
+package dtls
+
+import (
+	"fmt"
+	"sync"
+)
+
+// keyLogLabel marks the DTLS 1.2 master secrets in the NSS key log format
+// https://developer.mozilla.org/en-US/docs/Mozilla/Projects/NSS/Key_Log_Format
+const keyLogLabel = "CLIENT_RANDOM"
+
+// keyLogLock keeps the lines of Conns sharing a KeyLogWriter whole
+var keyLogLock sync.Mutex
+
+// writeKeyLog logs the master secret of the connection identified by
+// clientRandom, if the Config has a KeyLogWriter
+func (c *Conn) writeKeyLog(clientRandom, masterSecret []byte) error {
+	if c.keyLogWriter == nil {
+		return nil
+	}
+
+	keyLogLock.Lock()
+	defer keyLogLock.Unlock()
+
+	_, err := fmt.Fprintf(c.keyLogWriter, "%s %x %x\n", keyLogLabel, clientRandom, masterSecret)
+	return err
+}
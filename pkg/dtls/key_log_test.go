@@ -0,0 +1,28 @@
+package dtls
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteKeyLog(t *testing.T) {
+	c := &Conn{}
+	if err := c.writeKeyLog([]byte{0x01}, []byte{0x02}); err != nil {
+		t.Errorf("writeKeyLog without KeyLogWriter: %v", err)
+	}
+
+	keyLog := &bytes.Buffer{}
+	c.keyLogWriter = keyLog
+	clientRandom := bytes.Repeat([]byte{0xab}, 32)
+	masterSecret := bytes.Repeat([]byte{0x0c}, 48)
+	if err := c.writeKeyLog(clientRandom, masterSecret); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "CLIENT_RANDOM " +
+		"abababababababababababababababababababababababababababababababab " +
+		"0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c0c\n"
+	if keyLog.String() != expected {
+		t.Errorf("writeKeyLog: got %q, want %q", keyLog.String(), expected)
+	}
+}
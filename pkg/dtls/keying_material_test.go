@@ -0,0 +1,125 @@
+package dtls
+
+import (
+	"reflect"
+	"testing"
+)
+
+// The expected keying material was exported by OpenSSL 3.0
+// SSL_export_keying_material at the end of TLS 1.2 handshakes, along with
+// the master secret and randoms of the handshake. The exporter is the same
+// in DTLS 1.2.
+func TestExportKeyingMaterialContext(t *testing.T) {
+	label := []byte("EXPERIMENTAL dtls context")
+
+	for _, test := range []struct {
+		name                       string
+		cipherSuite                cipherSuite
+		masterSecret               []byte
+		clientRandom, serverRandom []byte
+		noContext                  []byte // context nil
+		emptyContext               []byte // context []byte{}
+		context                    []byte // context "context value"
+	}{
+		{
+			name:        "SHA-256 PRF",
+			cipherSuite: &cipherSuiteTLSEcdheEcdsaWithAes128GcmSha256{},
+			masterSecret: []byte{
+				0xa2, 0xef, 0xb6, 0xcc, 0xd9, 0x96, 0xd9, 0xcd, 0x15, 0xe1, 0xed, 0xf9, 0x15, 0x5c, 0x14, 0x82,
+				0x93, 0xbd, 0x12, 0x65, 0xab, 0x07, 0xf5, 0xe5, 0x31, 0xf1, 0xa9, 0x1f, 0xfc, 0x18, 0xcc, 0xcf,
+				0x51, 0x9a, 0x03, 0x04, 0xda, 0x03, 0x5f, 0xb2, 0x17, 0x5c, 0x0e, 0xcd, 0x1c, 0x4f, 0x75, 0x59,
+			},
+			clientRandom: []byte{
+				0xc6, 0x52, 0x9b, 0x38, 0xfb, 0xb9, 0xd8, 0xfd, 0xb9, 0xcd, 0xe7, 0x71, 0x05, 0x62, 0x75, 0x21,
+				0x08, 0x25, 0x7b, 0xa1, 0x14, 0x6d, 0x12, 0xe2, 0x65, 0x96, 0xc1, 0x01, 0xe4, 0x42, 0x2a, 0xdd,
+			},
+			serverRandom: []byte{
+				0x75, 0xb0, 0x27, 0x9b, 0x27, 0xb5, 0xb9, 0xf7, 0xb8, 0x47, 0x88, 0x42, 0xbf, 0x07, 0x75, 0x30,
+				0xcf, 0xe5, 0x26, 0x72, 0xa8, 0xfa, 0x7b, 0x47, 0xd6, 0x0e, 0x83, 0x85, 0x65, 0x4f, 0x9a, 0xac,
+			},
+			noContext: []byte{
+				0x57, 0x16, 0x79, 0xc9, 0x27, 0x06, 0xbd, 0xfe, 0xc8, 0x84, 0x05, 0x9c, 0x5f, 0x14, 0xb2, 0x1b,
+				0x3d, 0xf9, 0x3b, 0x57, 0x43, 0x76, 0xd0, 0x70, 0x79, 0x66, 0xc1, 0xf0, 0x2e, 0xc7, 0x10, 0x9f,
+			},
+			emptyContext: []byte{
+				0x5e, 0x6f, 0x52, 0xc4, 0x2a, 0xe2, 0x70, 0x9d, 0x13, 0x01, 0xb7, 0x68, 0x97, 0xd3, 0x04, 0x7f,
+				0x93, 0xe8, 0x5d, 0x0b, 0xd6, 0x27, 0x23, 0xa9, 0x83, 0xea, 0x0e, 0xc3, 0xf9, 0xcb, 0x23, 0x4f,
+			},
+			context: []byte{
+				0xb7, 0x47, 0x08, 0xc5, 0x39, 0xe5, 0xbf, 0x76, 0xa0, 0xf4, 0x25, 0x0b, 0x73, 0x34, 0x12, 0xa6,
+				0x65, 0x50, 0xd4, 0x15, 0xb9, 0x55, 0xed, 0x9d, 0x4e, 0xc4, 0x6a, 0xc2, 0x3b, 0xf5, 0x58, 0x58,
+			},
+		},
+		{
+			name:        "SHA-384 PRF",
+			cipherSuite: &cipherSuiteTLSEcdheEcdsaWithAes256GcmSha384{},
+			masterSecret: []byte{
+				0x00, 0x2b, 0x17, 0xb5, 0x11, 0xd0, 0xf8, 0x8d, 0x57, 0xef, 0x53, 0x9b, 0x5a, 0xbb, 0x5b, 0xd6,
+				0xb4, 0xeb, 0x5e, 0x4e, 0x1b, 0x91, 0xc8, 0xf4, 0xe5, 0x28, 0x54, 0xa4, 0xf7, 0x8d, 0x4a, 0x53,
+				0x4d, 0xac, 0xee, 0x7e, 0xbd, 0xa9, 0x3a, 0x6e, 0xd6, 0x8f, 0x00, 0x3c, 0x10, 0xc9, 0x63, 0x87,
+			},
+			clientRandom: []byte{
+				0x17, 0x8a, 0x1f, 0xbe, 0x91, 0x98, 0xba, 0x32, 0x1c, 0x6d, 0xf0, 0xcf, 0x83, 0x1a, 0xee, 0x07,
+				0x66, 0x2e, 0xeb, 0xca, 0xf3, 0xbf, 0x58, 0xca, 0xdc, 0x7b, 0xa8, 0xb8, 0xd4, 0x1b, 0x55, 0x41,
+			},
+			serverRandom: []byte{
+				0xf5, 0x6f, 0xfc, 0x0b, 0x3e, 0x14, 0xed, 0xa1, 0x98, 0xa9, 0x17, 0xf5, 0x80, 0x0a, 0x4b, 0x42,
+				0xbc, 0x25, 0x94, 0x34, 0x3a, 0x19, 0xf3, 0x9d, 0xc0, 0xf5, 0xa4, 0x3e, 0xdc, 0x9e, 0x13, 0x3f,
+			},
+			noContext: []byte{
+				0xe8, 0x70, 0xeb, 0xe1, 0xf6, 0xa3, 0xf9, 0x64, 0x29, 0x35, 0x56, 0x8f, 0x16, 0x78, 0xf7, 0x2d,
+				0xa0, 0x9e, 0x91, 0x64, 0x87, 0x71, 0xfd, 0xdb, 0x5e, 0xb2, 0x31, 0xef, 0x46, 0x83, 0x76, 0xc3,
+			},
+			emptyContext: []byte{
+				0x2e, 0x87, 0xf2, 0xe7, 0xfe, 0xe3, 0x1e, 0xd2, 0xa2, 0x5b, 0xe4, 0x8b, 0xf5, 0xbe, 0x9d, 0x8d,
+				0x2c, 0x51, 0x3f, 0x4a, 0xa4, 0xb2, 0x66, 0xac, 0x5e, 0xa6, 0x51, 0x8f, 0xcc, 0x8f, 0xdb, 0x2f,
+			},
+			context: []byte{
+				0x32, 0x93, 0x6c, 0x61, 0x41, 0xb3, 0x1d, 0xe9, 0xa7, 0x78, 0x08, 0x34, 0x1f, 0xed, 0x8c, 0xd5,
+				0x2e, 0x41, 0xc6, 0xd3, 0x93, 0x13, 0xd7, 0x9f, 0x99, 0x0e, 0x80, 0xe7, 0xca, 0xc2, 0x17, 0xd4,
+			},
+		},
+	} {
+		for _, isClient := range []bool{true, false} {
+			c := &Conn{
+				localEpoch:   1,
+				isClient:     isClient,
+				cipherSuite:  test.cipherSuite,
+				masterSecret: test.masterSecret,
+			}
+			local, remote := test.clientRandom, test.serverRandom
+			if !isClient {
+				local, remote = remote, local
+			}
+			if err := c.localRandom.Unmarshal(local); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.remoteRandom.Unmarshal(remote); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, export := range []struct {
+				context, expected []byte
+			}{
+				{nil, test.noContext},
+				{[]byte{}, test.emptyContext},
+				{[]byte("context value"), test.context},
+			} {
+				out, err := c.ExportKeyingMaterial(label, export.context, len(export.expected))
+				if err != nil {
+					t.Fatal(err)
+				} else if !reflect.DeepEqual(out, export.expected) {
+					t.Errorf("ExportKeyingMaterial %s client %t context %q: got %#v, want %#v",
+						test.name, isClient, export.context, out, export.expected)
+				}
+			}
+		}
+	}
+}
+
+func TestExportKeyingMaterialContextTooLong(t *testing.T) {
+	c := &Conn{localEpoch: 1, cipherSuite: &cipherSuiteTLSEcdheEcdsaWithAes128GcmSha256{}}
+	if _, err := c.ExportKeyingMaterial([]byte("label"), make([]byte, 0x10000), 16); err != errContextTooLong {
+		t.Errorf("ExportKeyingMaterial context too long: got %v, want %v", err, errContextTooLong)
+	}
+}
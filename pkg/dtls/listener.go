@@ -3,50 +3,300 @@ package dtls
 import (
 	"errors"
 	"net"
+	"sync"
+	"sync/atomic"
 
 	"github.com/thomas-fossati/dtls/pkg/dtls/internal/udp"
+	"github.com/thomas-fossati/dtls/pkg/logging"
 )
 
+const defaultAcceptBacklog = 128
+
 // Listen creates a DTLS listener
 func Listen(network string, laddr *net.UDPAddr, config *Config) (*Listener, error) {
+	pConn, err := net.ListenUDP(network, laddr)
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := NewListener(pConn, config)
+	if err != nil {
+		_ = pConn.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// NewListener creates a DTLS listener that serves on pConn, a socket the
+// caller already owns, such as one inherited from the service manager or
+// an in-memory transport. The listener then owns pConn, and closes it once
+// the listener and all the accepted connections are closed. On error,
+// pConn is left open.
+func NewListener(pConn net.PacketConn, config *Config) (*Listener, error) {
 	if config == nil {
 		return nil, errors.New("No config provided")
 	}
-	parent, err := udp.Listen(network, laddr)
+
+	// All accepted Conns verify the cookies the Listener generates
+	listenerConfig := *config
+	var err error
+	listenerConfig.CookieGenerator, listenerConfig.CookieVerifier, err = configCookies(config)
 	if err != nil {
 		return nil, err
 	}
 
-	// configure connection id attributes for incoming UDP packets
-	parent.SetCidLen(extensionConnectionIdSize)
+	backlog := config.AcceptBacklog
+	if backlog < 1 {
+		backlog = defaultAcceptBacklog
+	}
+
+	l := &Listener{
+		config:   &listenerConfig,
+		pConn:    pConn,
+		log:      logging.NewLogger(config.LoggerFactory, "dtls"),
+		backlog:  make(chan struct{}, backlog),
+		acceptCh: make(chan *Conn, backlog),
+		doneCh:   make(chan struct{}),
+		pending:  map[*udp.Conn]struct{}{},
+		conns:    map[*Conn]struct{}{},
+	}
+	// The parent reads from pConn right away, so it is given the filter and
+	// connection id attributes up front
+	l.parent = udp.NewListener(pConn, &udp.ListenerConfig{
+		CidLen:        extensionConnectionIdSize,
+		AcceptFilter:  l.verifyHello,
+		LoggerFactory: config.LoggerFactory,
+	})
+
+	go l.acceptLoop()
 
-	return &Listener{
-		config: config,
-		parent: parent,
-	}, nil
+	return l, nil
 }
 
 // Listener represents a DTLS listener
 type Listener struct {
+	helloVerifyRequests uint64 // accessed atomically, first for 64-bit alignment
+
 	config *Config
+	pConn  net.PacketConn // answers ClientHellos without a Conn
 	parent *udp.Listener
+	log    logging.LeveledLogger
+
+	backlog  chan struct{} // holds a slot per Conn handshaking or in acceptCh
+	acceptCh chan *Conn    // handshaken Conns
+	doneCh   chan struct{}
+
+	lock       sync.Mutex
+	isClosed   bool
+	pending    map[*udp.Conn]struct{} // handshaking
+	conns      map[*Conn]struct{}     // handshaken Conns that are still open
+	closed     ConnStats              // sums the counters of the closed Conns
+	handshakes uint64
 }
 
-// Accept waits for and returns the next connection to the listener.
-// You have to either close or read on all connection that are created.
-func (l *Listener) Accept() (net.Conn, error) {
-	c, err := l.parent.Accept()
+// verifyHello is called with the first datagram of an unknown client, a
+// Conn is only created for a ClientHello that carries a valid cookie. Any
+// other ClientHello is answered with a HelloVerifyRequest without keeping
+// any state, the client proves it can receive at its address by sending
+// the cookie back. With InsecureSkipHelloVerify any ClientHello is
+// accepted. https://tools.ietf.org/html/rfc6347#section-4.2.1
+func (l *Listener) verifyHello(raddr net.Addr, pkt []byte) bool {
+	pkts, err := unpackDatagram(pkt)
+	if err != nil || len(pkts) == 0 {
+		return false
+	}
+
+	r := &recordLayer{}
+	if err := r.Unmarshal(pkts[0]); err != nil || r.recordLayerHeader.epoch != 0 {
+		return false
+	}
+	h, ok := r.content.(*handshake)
+	if !ok {
+		return false
+	}
+	clientHello, ok := h.handshakeMessage.(*handshakeMessageClientHello)
+	if !ok {
+		return false
+	}
+
+	if l.config.InsecureSkipHelloVerify {
+		return true
+	}
+
+	params := cookieParams(clientHello)
+	if l.config.CookieVerifier.VerifyCookie(raddr, params, clientHello.cookie) {
+		return true
+	}
+
+	cookie, err := l.config.CookieGenerator.GenerateCookie(raddr, params)
 	if err != nil {
-		return nil, err
+		return false
+	}
+
+	// The HelloVerifyRequest takes the record sequence number of the
+	// ClientHello, so that retransmissions don't repeat sequence numbers
+	// https://tools.ietf.org/html/rfc6347#section-4.2.1
+	raw, err := (&recordLayer{
+		recordLayerHeader: recordLayerHeader{
+			sequenceNumber:  r.recordLayerHeader.sequenceNumber,
+			protocolVersion: protocolVersion1_2,
+		},
+		content: &handshake{
+			handshakeMessage: &handshakeMessageHelloVerifyRequest{
+				version: protocolVersion1_2,
+				cookie:  cookie,
+			},
+		},
+	}).Marshal()
+	if err != nil {
+		return false
+	}
+
+	atomic.AddUint64(&l.helloVerifyRequests, 1)
+	l.log.Tracef("%s: sent HelloVerifyRequest", raddr)
+	_, _ = l.pConn.WriteTo(raw, raddr)
+	return false
+}
+
+// acceptLoop starts a handshake for every new client. The clients that
+// come while the backlog is full are dropped, they retransmit their
+// ClientHello later. The parent is never kept waiting, it delivers the
+// packets of all the clients.
+func (l *Listener) acceptLoop() {
+	for {
+		c, err := l.parent.Accept()
+		if err != nil {
+			return
+		}
+
+		select {
+		case l.backlog <- struct{}{}:
+		default:
+			l.log.Debugf("%s: accept backlog full, dropping connection", c.RemoteAddr())
+			_ = c.Close()
+			continue
+		}
+
+		l.lock.Lock()
+		if l.isClosed {
+			l.lock.Unlock()
+			_ = c.Close()
+			<-l.backlog
+			return
+		}
+		l.pending[c] = struct{}{}
+		l.lock.Unlock()
+
+		go l.handshake(c)
+	}
+}
+
+// handshake runs the handshake of c, and queues the Conn for Accept
+func (l *Listener) handshake(c *udp.Conn) {
+	conn, err := Server(c, l.config)
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	delete(l.pending, c)
+	if err != nil {
+		l.log.Debugf("%s: handshake failed: %v", c.RemoteAddr(), err)
+		_ = c.Close()
+		<-l.backlog
+		return
+	} else if l.isClosed {
+		_ = conn.Close()
+		<-l.backlog
+		return
+	}
+	l.pruneConns()
+	l.conns[conn] = struct{}{}
+	l.handshakes++
+
+	// acceptCh has room for the whole backlog
+	l.acceptCh <- conn
+}
+
+// Accept waits for and returns the next connection to the listener, once
+// its handshake completed. The handshakes run concurrently in the
+// background. You have to either close or read on all connection that are
+// created.
+func (l *Listener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.acceptCh:
+		<-l.backlog
+		return conn, nil
+	case <-l.doneCh:
+		return nil, errClosedListener
+	}
+}
+
+// pruneConns folds the counters of the closed Conns into l.closed, so that
+// Listener doesn't hold on to them. The caller must hold l.lock.
+func (l *Listener) pruneConns() {
+	for c := range l.conns {
+		select {
+		case <-c.closed:
+			l.closed.add(c.Stats())
+			delete(l.conns, c)
+		default:
+		}
+	}
+}
+
+// Stats returns the counters of the listener, Conns sums the counters of
+// all the connections it accepted
+func (l *Listener) Stats() ListenerStats {
+	parent := l.parent.Stats()
+	s := ListenerStats{
+		ActiveConnections:   parent.ActiveConns,
+		HelloVerifyRequests: atomic.LoadUint64(&l.helloVerifyRequests),
+		UnknownCIDDrops:     parent.UnknownCidDrops,
+		Migrations:          parent.Migrations,
+		QueueDrops:          parent.QueueDrops,
 	}
-	return Server(c, l.config)
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.pruneConns()
+	s.Handshakes = l.handshakes
+	s.Conns = l.closed
+	for c := range l.conns {
+		s.Conns.add(c.Stats())
+	}
+	return s
 }
 
 // Close closes the listener.
 // Any blocked Accept operations will be unblocked and return errors.
-// Already Accepted connections are not closed.
+// Already Accepted connections are not closed, the ones still
+// handshaking or waiting for Accept are.
 func (l *Listener) Close() error {
-	return l.parent.Close()
+	l.lock.Lock()
+	if l.isClosed {
+		l.lock.Unlock()
+		return nil
+	}
+	l.isClosed = true
+	close(l.doneCh)
+	pending := make([]*udp.Conn, 0, len(l.pending))
+	for c := range l.pending {
+		pending = append(pending, c)
+	}
+	l.lock.Unlock()
+
+	// The handshakes fail, and their goroutines release the Conns
+	for _, c := range pending {
+		_ = c.Close()
+	}
+
+	for {
+		select {
+		case conn := <-l.acceptCh:
+			_ = conn.Close()
+		default:
+			return l.parent.Close()
+		}
+	}
 }
 
 // Addr returns the listener's network address.
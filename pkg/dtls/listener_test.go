@@ -0,0 +1,185 @@
+package dtls
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func listenerConfig(t *testing.T) *Config {
+	cert, key, err := GenerateSelfSigned()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Config{Certificate: cert, PrivateKey: key}
+}
+
+// dialListener completes a handshake against l and returns both ends
+func dialListener(t *testing.T, l *Listener, config *Config) (net.Conn, *Conn) {
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		s, err := l.Accept()
+		if err != nil {
+			t.Error(err)
+		}
+		accepted <- s
+	}()
+
+	c, err := Dial("udp", l.Addr().(*net.UDPAddr), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return <-accepted, c
+}
+
+func TestListenerMalformedClientHello(t *testing.T) {
+	l, err := Listen("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}, listenerConfig(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = l.Close()
+	}()
+
+	// A ClientHello declaring an odd cipher suites length
+	raw := []byte{
+		0x16, 0xfe, 0xfd, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x34,
+		0x01, 0x00, 0x00, 0x28, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x28,
+		0xfe, 0xfd,
+	}
+	raw = append(raw, make([]byte, handshakeRandomLength+2)...)
+	raw = append(raw, 0x00, 0x03, 0xc0, 0x2b)
+
+	pc, err := net.DialUDP("udp", nil, l.Addr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = pc.Close()
+	}()
+	if _, err = pc.Write(raw); err != nil {
+		t.Fatal(err)
+	}
+
+	// The listener must still serve well formed clients
+	s, c := dialListener(t, l, listenerConfig(t))
+	_ = c.Close()
+	_ = s.Close()
+}
+
+// deafConn discards everything it receives
+type deafConn struct {
+	net.Conn
+}
+
+func (c deafConn) Read(p []byte) (int, error) {
+	for {
+		if _, err := c.Conn.Read(p); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// stallHandshake starts a client that never gets past its ClientHello
+func stallHandshake(t *testing.T, l *Listener) net.Conn {
+	pc, err := net.DialUDP("udp", nil, l.Addr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_, _ = Client(deafConn{pc}, &Config{})
+	}()
+	waitActiveConnections(t, l, 1)
+	return pc
+}
+
+func waitActiveConnections(t *testing.T, l *Listener, n uint64) {
+	for i := 0; l.Stats().ActiveConnections != n; i++ {
+		if i == 100 {
+			t.Fatalf("ActiveConnections: got %d, want %d", l.Stats().ActiveConnections, n)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestListenerStalledHandshake(t *testing.T) {
+	config := listenerConfig(t)
+	config.InsecureSkipHelloVerify = true
+	l, err := Listen("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = l.Close()
+	}()
+
+	stalled := stallHandshake(t, l)
+	defer func() {
+		_ = stalled.Close()
+	}()
+
+	// Accept skips the stalled handshake, and doesn't wait for it to time
+	// out
+	start := time.Now()
+	s, c := dialListener(t, l, listenerConfig(t))
+	defer func() {
+		_ = c.Close()
+		_ = s.Close()
+	}()
+	if s.RemoteAddr().String() != c.LocalAddr().String() {
+		t.Errorf("Accept: got %v, want %v", s.RemoteAddr(), c.LocalAddr())
+	}
+	if elapsed := time.Since(start); elapsed > defaultHandshakeTimeout/2 {
+		t.Errorf("Accept: took %v", elapsed)
+	}
+	if got := l.Stats().Handshakes; got != 1 {
+		t.Errorf("Handshakes: got %d, want %d", got, 1)
+	}
+}
+
+func TestListenerAcceptBacklog(t *testing.T) {
+	config := listenerConfig(t)
+	config.InsecureSkipHelloVerify = true
+	config.AcceptBacklog = 1
+	l, err := Listen("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = l.Close()
+	}()
+
+	stalled := stallHandshake(t, l)
+	defer func() {
+		_ = stalled.Close()
+	}()
+
+	// The stalled handshake holds the only slot, new remotes are dropped
+	clientConfig := listenerConfig(t)
+	clientConfig.HandshakeTimeout = 2 * time.Second
+	if _, err := Dial("udp", l.Addr().(*net.UDPAddr), clientConfig); err != errHandshakeTimeout {
+		t.Errorf("Dial: got %v, want %v", err, errHandshakeTimeout)
+	}
+}
+
+func TestListenerClosePending(t *testing.T) {
+	config := listenerConfig(t)
+	config.InsecureSkipHelloVerify = true
+	l, err := Listen("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stalled := stallHandshake(t, l)
+	defer func() {
+		_ = stalled.Close()
+	}()
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	waitActiveConnections(t, l, 0)
+	if _, err := l.Accept(); err != errClosedListener {
+		t.Errorf("Accept: got %v, want %v", err, errClosedListener)
+	}
+}
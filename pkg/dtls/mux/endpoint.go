@@ -0,0 +1,116 @@
+package mux
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/thomas-fossati/dtls/pkg/dtls/internal/deadline"
+)
+
+type packet struct {
+	data []byte
+	addr net.Addr
+}
+
+// Endpoint is the net.PacketConn of one of the protocols sharing the
+// socket of a Mux
+type Endpoint struct {
+	mux   *Mux
+	match MatchFunc
+
+	readCh    chan packet
+	doneCh    chan struct{}
+	closeOnce sync.Once
+
+	readDeadline  *deadline.Deadline
+	writeDeadline *deadline.Deadline
+}
+
+func newEndpoint(m *Mux, f MatchFunc) *Endpoint {
+	return &Endpoint{
+		mux:    m,
+		match:  f,
+		readCh: make(chan packet, endpointQueueSize),
+		doneCh: make(chan struct{}),
+
+		readDeadline:  deadline.New(),
+		writeDeadline: deadline.New(),
+	}
+}
+
+// enqueue hands a datagram to the readers, it is dropped if the queue is
+// full so that a slow Endpoint doesn't hold up the others
+func (e *Endpoint) enqueue(data []byte, addr net.Addr) {
+	select {
+	case e.readCh <- packet{data, addr}:
+	default:
+	}
+}
+
+func (e *Endpoint) close() {
+	e.closeOnce.Do(func() {
+		close(e.doneCh)
+	})
+}
+
+// ReadFrom reads the next datagram matched to the Endpoint, a datagram
+// longer than p is truncated
+func (e *Endpoint) ReadFrom(p []byte) (int, net.Addr, error) {
+	select {
+	case pkt := <-e.readCh:
+		return copy(p, pkt.data), pkt.addr, nil
+	case <-e.readDeadline.Done():
+		return 0, nil, deadline.ErrTimeout
+	case <-e.doneCh:
+		return 0, nil, errClosedEndpoint
+	}
+}
+
+// WriteTo writes a datagram to addr through the socket of the Mux
+func (e *Endpoint) WriteTo(p []byte, addr net.Addr) (int, error) {
+	select {
+	case <-e.doneCh:
+		return 0, errClosedEndpoint
+	default:
+	}
+	if e.writeDeadline.Exceeded() {
+		return 0, deadline.ErrTimeout
+	}
+	return e.mux.pConn.WriteTo(p, addr)
+}
+
+// Close stops the delivery of datagrams to the Endpoint and releases any
+// ReadFrom calls, the Mux and its other Endpoints stay open
+func (e *Endpoint) Close() error {
+	e.mux.removeEndpoint(e)
+	e.close()
+	return nil
+}
+
+// LocalAddr returns the address of the socket of the Mux
+func (e *Endpoint) LocalAddr() net.Addr {
+	return e.mux.LocalAddr()
+}
+
+// SetDeadline sets the read and write deadlines
+func (e *Endpoint) SetDeadline(t time.Time) error {
+	e.readDeadline.Set(t)
+	e.writeDeadline.Set(t)
+	return nil
+}
+
+// SetReadDeadline sets the time after which ReadFrom fails with a timeout,
+// the zero time means ReadFrom doesn't time out
+func (e *Endpoint) SetReadDeadline(t time.Time) error {
+	e.readDeadline.Set(t)
+	return nil
+}
+
+// SetWriteDeadline sets the time after which WriteTo fails with a timeout.
+// The deadline is only checked before writing to the shared PacketConn,
+// whose own write deadline is left to the Mux's owner.
+func (e *Endpoint) SetWriteDeadline(t time.Time) error {
+	e.writeDeadline.Set(t)
+	return nil
+}
@@ -0,0 +1,74 @@
+package mux
+
+// MatchFunc tells whether a datagram belongs to an Endpoint
+type MatchFunc func(buf []byte) bool
+
+// MatchAll matches every datagram
+func MatchAll(buf []byte) bool {
+	return true
+}
+
+// MatchRange returns a MatchFunc that matches the datagrams whose first
+// byte is between lower and upper, inclusive
+func MatchRange(lower, upper byte) MatchFunc {
+	return func(buf []byte) bool {
+		return len(buf) > 0 && buf[0] >= lower && buf[0] <= upper
+	}
+}
+
+// The first byte ranges of the protocols sharing a 5-tuple
+// https://tools.ietf.org/html/rfc7983#section-7
+//
+//                  +----------------+
+//                  |        [0..3] -+--> forward to STUN
+//                  |                |
+//                  |      [16..19] -+--> forward to ZRTP
+//                  |                |
+//      packet -->  |      [20..63] -+--> forward to DTLS
+//                  |                |
+//                  |      [64..79] -+--> forward to TURN Channel
+//                  |                |
+//                  |    [128..191] -+--> forward to RTP/RTCP
+//                  +----------------+
+
+// MatchSTUN matches STUN messages
+func MatchSTUN(buf []byte) bool {
+	return MatchRange(0, 3)(buf)
+}
+
+// MatchZRTP matches ZRTP messages
+func MatchZRTP(buf []byte) bool {
+	return MatchRange(16, 19)(buf)
+}
+
+// MatchDTLS matches DTLS records
+func MatchDTLS(buf []byte) bool {
+	return MatchRange(20, 63)(buf)
+}
+
+// MatchTURN matches TURN ChannelData messages
+func MatchTURN(buf []byte) bool {
+	return MatchRange(64, 79)(buf)
+}
+
+// MatchSRTPOrSRTCP matches SRTP and SRTCP packets
+func MatchSRTPOrSRTCP(buf []byte) bool {
+	return MatchRange(128, 191)(buf)
+}
+
+// isRTCP tells RTCP from RTP packets by their packet type, RTP payload
+// types don't use the range of the RTCP packet types
+// https://tools.ietf.org/html/rfc5761#section-4
+func isRTCP(buf []byte) bool {
+	return len(buf) >= 2 && buf[1] >= 192 && buf[1] <= 223
+}
+
+// MatchSRTP matches SRTP packets
+func MatchSRTP(buf []byte) bool {
+	return MatchSRTPOrSRTCP(buf) && !isRTCP(buf)
+}
+
+// MatchSRTCP matches SRTCP packets
+func MatchSRTCP(buf []byte) bool {
+	return MatchSRTPOrSRTCP(buf) && isRTCP(buf)
+}
@@ -0,0 +1,41 @@
+package mux
+
+import "testing"
+
+func TestMatchFuncs(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		buf   []byte
+		match MatchFunc
+	}{
+		{"STUN", []byte{0x00, 0x01}, MatchSTUN},
+		{"ZRTP", []byte{0x10}, MatchZRTP},
+		{"DTLS", []byte{0x16, 0xfe, 0xfd}, MatchDTLS},
+		{"TURN", []byte{0x40, 0x00}, MatchTURN},
+		{"SRTP", []byte{0x80, 0x60}, MatchSRTP},
+		{"SRTCP", []byte{0x81, 0xc8}, MatchSRTCP},
+	} {
+		for _, f := range []struct {
+			name  string
+			match MatchFunc
+		}{
+			{"STUN", MatchSTUN},
+			{"ZRTP", MatchZRTP},
+			{"DTLS", MatchDTLS},
+			{"TURN", MatchTURN},
+			{"SRTP", MatchSRTP},
+			{"SRTCP", MatchSRTCP},
+		} {
+			if expected := f.name == test.name; f.match(test.buf) != expected {
+				t.Errorf("Match%s(%s): got %v, want %v", f.name, test.name, !expected, expected)
+			}
+		}
+		if !MatchSRTPOrSRTCP(test.buf) && (test.name == "SRTP" || test.name == "SRTCP") {
+			t.Errorf("MatchSRTPOrSRTCP(%s): got false, want true", test.name)
+		}
+	}
+
+	if MatchDTLS(nil) || !MatchAll(nil) {
+		t.Error("MatchDTLS matched an empty datagram, or MatchAll didn't")
+	}
+}
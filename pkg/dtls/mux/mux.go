@@ -0,0 +1,120 @@
+// Package mux demultiplexes the datagrams received on a single socket,
+// such as the one a WebRTC peer shares between ICE, DTLS and SRTP, by
+// their first byte. Each Endpoint is a net.PacketConn, that can be served
+// with dtls.NewListener or dtls.DialWithPacketConn.
+// https://tools.ietf.org/html/rfc7983
+package mux
+
+import (
+	"errors"
+	"net"
+	"sync"
+)
+
+// receiveMTU holds the largest UDP datagram
+const receiveMTU = 65535
+
+// endpointQueueSize is the number of datagrams an Endpoint holds before
+// it drops the new ones, like a full socket buffer
+const endpointQueueSize = 64
+
+var errClosedEndpoint = errors.New("mux: endpoint closed")
+
+// Mux dispatches the datagrams received on a PacketConn to its Endpoints
+type Mux struct {
+	pConn net.PacketConn
+
+	lock      sync.RWMutex
+	endpoints []*Endpoint
+	closed    bool
+}
+
+// NewMux creates a Mux that reads from pConn, it then owns pConn and
+// closes it when it is closed
+func NewMux(pConn net.PacketConn) *Mux {
+	m := &Mux{pConn: pConn}
+
+	go m.readLoop()
+
+	return m
+}
+
+// NewEndpoint creates an Endpoint that receives the datagrams f matches.
+// A datagram goes to the first Endpoint, in creation order, that matches
+// it. The datagrams no Endpoint matches are dropped.
+func (m *Mux) NewEndpoint(f MatchFunc) *Endpoint {
+	e := newEndpoint(m, f)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.closed {
+		e.close()
+	} else {
+		m.endpoints = append(m.endpoints, e)
+	}
+	return e
+}
+
+// removeEndpoint stops dispatching to e
+func (m *Mux) removeEndpoint(e *Endpoint) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for i := range m.endpoints {
+		if m.endpoints[i] == e {
+			m.endpoints = append(m.endpoints[:i], m.endpoints[i+1:]...)
+			return
+		}
+	}
+}
+
+// Close closes the PacketConn and all the Endpoints
+func (m *Mux) Close() error {
+	m.lock.Lock()
+	if m.closed {
+		m.lock.Unlock()
+		return nil
+	}
+	m.closed = true
+	endpoints := m.endpoints
+	m.endpoints = nil
+	m.lock.Unlock()
+
+	for _, e := range endpoints {
+		e.close()
+	}
+	return m.pConn.Close()
+}
+
+// LocalAddr returns the address of the PacketConn
+func (m *Mux) LocalAddr() net.Addr {
+	return m.pConn.LocalAddr()
+}
+
+// readLoop dispatches the datagrams until the PacketConn fails
+func (m *Mux) readLoop() {
+	defer func() {
+		_ = m.Close()
+	}()
+
+	buf := make([]byte, receiveMTU)
+	for {
+		n, raddr, err := m.pConn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		m.dispatch(buf[:n], raddr)
+	}
+}
+
+func (m *Mux) dispatch(pkt []byte, raddr net.Addr) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	for _, e := range m.endpoints {
+		if e.match(pkt) {
+			e.enqueue(append([]byte{}, pkt...), raddr)
+			return
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package mux
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pions/transport/test"
+)
+
+func TestMux(t *testing.T) {
+	lim := test.TimeOut(time.Second * 5)
+	defer lim.Stop()
+
+	pConn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewMux(pConn)
+	stun := m.NewEndpoint(MatchSTUN)
+	dtls := m.NewEndpoint(MatchDTLS)
+
+	remote, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = remote.Close()
+	}()
+
+	// The datagram no Endpoint matches is dropped
+	for _, pkt := range []string{"\x80rtp", "\x16dtls", "\x00stun"} {
+		if _, err = remote.WriteTo([]byte(pkt), m.LocalAddr()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buf := make([]byte, 16)
+	for _, e := range []struct {
+		endpoint *Endpoint
+		expected string
+	}{{dtls, "\x16dtls"}, {stun, "\x00stun"}} {
+		n, addr, err := e.endpoint.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		} else if string(buf[:n]) != e.expected || addr.String() != remote.LocalAddr().String() {
+			t.Errorf("Endpoint ReadFrom: got %q from %v, want %q from %v", buf[:n], addr, e.expected, remote.LocalAddr())
+		}
+	}
+
+	if _, err = stun.WriteTo([]byte("\x01reply"), remote.LocalAddr()); err != nil {
+		t.Fatal(err)
+	}
+	if n, _, err := remote.ReadFrom(buf); err != nil {
+		t.Fatal(err)
+	} else if string(buf[:n]) != "\x01reply" {
+		t.Errorf("Endpoint WriteTo: got %q, want %q", buf[:n], "\x01reply")
+	}
+
+	// Closing an Endpoint leaves the others open
+	if err = dtls.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = dtls.ReadFrom(buf); err != errClosedEndpoint {
+		t.Errorf("ReadFrom closed Endpoint: got %v, want %v", err, errClosedEndpoint)
+	}
+	if err = stun.SetReadDeadline(time.Now().Add(10 * time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = stun.ReadFrom(buf); err == nil {
+		t.Error("ReadFrom after the deadline: got nil error")
+	} else if nerr, ok := err.(net.Error); !ok || !nerr.Timeout() {
+		t.Errorf("ReadFrom after the deadline: got %v, want a timeout", err)
+	}
+
+	if err = stun.SetReadDeadline(time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = stun.ReadFrom(buf); err != errClosedEndpoint {
+		t.Errorf("ReadFrom after Mux Close: got %v, want %v", err, errClosedEndpoint)
+	}
+}
@@ -7,28 +7,29 @@ import (
 	"golang.org/x/crypto/curve25519"
 )
 
+// NamedCurve is the elliptic curve of the ECDHE key exchange
 // https://www.iana.org/assignments/tls-parameters/tls-parameters.xml#tls-parameters-8
-type namedCurve uint16
+type NamedCurve uint16
 
 type namedCurveKeypair struct {
-	curve      namedCurve
+	curve      NamedCurve
 	publicKey  []byte
 	privateKey []byte
 }
 
 const (
-	namedCurveP256   namedCurve = 0x0017
-	namedCurveX25519 namedCurve = 0x001d
+	NamedCurveP256   NamedCurve = 0x0017
+	NamedCurveX25519 NamedCurve = 0x001d
 )
 
-var namedCurves = map[namedCurve]bool{
-	namedCurveX25519: true,
-	namedCurveP256:   true,
+var namedCurves = map[NamedCurve]bool{
+	NamedCurveX25519: true,
+	NamedCurveP256:   true,
 }
 
-func generateKeypair(c namedCurve) (*namedCurveKeypair, error) {
+func generateKeypair(c NamedCurve) (*namedCurveKeypair, error) {
 	switch c {
-	case namedCurveX25519:
+	case NamedCurveX25519:
 		tmp := make([]byte, 32)
 		if _, err := rand.Read(tmp); err != nil {
 			return nil, err
@@ -38,14 +39,14 @@ func generateKeypair(c namedCurve) (*namedCurveKeypair, error) {
 		copy(private[:], tmp)
 
 		curve25519.ScalarBaseMult(&public, &private)
-		return &namedCurveKeypair{namedCurveX25519, public[:], private[:]}, nil
-	case namedCurveP256:
+		return &namedCurveKeypair{NamedCurveX25519, public[:], private[:]}, nil
+	case NamedCurveP256:
 		privateKey, x, y, err := elliptic.GenerateKey(elliptic.P256(), rand.Reader)
 		if err != nil {
 			return nil, err
 		}
 
-		return &namedCurveKeypair{namedCurveP256, elliptic.Marshal(elliptic.P256(), x, y), privateKey}, nil
+		return &namedCurveKeypair{NamedCurveP256, elliptic.Marshal(elliptic.P256(), x, y), privateKey}, nil
 	}
 	return nil, errInvalidNamedCurve
 }
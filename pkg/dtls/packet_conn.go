@@ -0,0 +1,54 @@
+package dtls
+
+import (
+	"net"
+	"time"
+)
+
+// packetConn is a net.Conn that exchanges datagrams with a single remote
+// over a net.PacketConn
+type packetConn struct {
+	pConn net.PacketConn
+	rAddr net.Addr
+}
+
+// Read returns the next datagram from rAddr, the datagrams from other
+// addresses are discarded
+func (c *packetConn) Read(b []byte) (int, error) {
+	for {
+		n, addr, err := c.pConn.ReadFrom(b)
+		if err != nil {
+			return n, err
+		} else if addr.String() == c.rAddr.String() {
+			return n, nil
+		}
+	}
+}
+
+func (c *packetConn) Write(b []byte) (int, error) {
+	return c.pConn.WriteTo(b, c.rAddr)
+}
+
+func (c *packetConn) Close() error {
+	return c.pConn.Close()
+}
+
+func (c *packetConn) LocalAddr() net.Addr {
+	return c.pConn.LocalAddr()
+}
+
+func (c *packetConn) RemoteAddr() net.Addr {
+	return c.rAddr
+}
+
+func (c *packetConn) SetDeadline(t time.Time) error {
+	return c.pConn.SetDeadline(t)
+}
+
+func (c *packetConn) SetReadDeadline(t time.Time) error {
+	return c.pConn.SetReadDeadline(t)
+}
+
+func (c *packetConn) SetWriteDeadline(t time.Time) error {
+	return c.pConn.SetWriteDeadline(t)
+}
@@ -0,0 +1,55 @@
+package dtls
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestPacketConn(t *testing.T) {
+	listen := func() net.PacketConn {
+		pConn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pConn
+	}
+	local, remote, other := listen(), listen(), listen()
+	defer func() {
+		_ = remote.Close()
+		_ = other.Close()
+	}()
+
+	c := withCid(&packetConn{pConn: local, rAddr: remote.LocalAddr()})
+	defer func() {
+		_ = c.Close()
+	}()
+	if err := c.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The datagram from another address is discarded
+	if _, err := other.WriteTo([]byte("other"), local.LocalAddr()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := remote.WriteTo([]byte("remote"), local.LocalAddr()); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 16)
+	n, err := c.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	} else if string(buf[:n]) != "remote" {
+		t.Errorf("packetConn Read: got %q, want %q", buf[:n], "remote")
+	}
+
+	if _, err = c.Write([]byte("reply")); err != nil {
+		t.Fatal(err)
+	}
+	n, addr, err := remote.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	} else if string(buf[:n]) != "reply" || addr.String() != local.LocalAddr().String() {
+		t.Errorf("packetConn Write: got %q from %v, want %q from %v", buf[:n], addr, "reply", local.LocalAddr())
+	}
+}
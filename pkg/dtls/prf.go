@@ -3,7 +3,6 @@ package dtls
 import (
 	"crypto/elliptic"
 	"crypto/hmac"
-	"crypto/sha1" // #nosec
 	"encoding/binary"
 	"fmt"
 	"hash"
@@ -13,10 +12,11 @@ import (
 )
 
 const (
-	prfMasterSecretLabel     = "master secret"
-	prfKeyExpansionLabel     = "key expansion"
-	prfVerifyDataClientLabel = "client finished"
-	prfVerifyDataServerLabel = "server finished"
+	prfMasterSecretLabel         = "master secret"
+	prfExtendedMasterSecretLabel = "extended master secret"
+	prfKeyExpansionLabel         = "key expansion"
+	prfVerifyDataClientLabel     = "client finished"
+	prfVerifyDataServerLabel     = "server finished"
 )
 
 type hashFunc func() hash.Hash
@@ -50,16 +50,16 @@ func (e *encryptionKeys) String() string {
 		e.serverWriteIV)
 }
 
-func prfPreMasterSecret(publicKey, privateKey []byte, curve namedCurve) ([]byte, error) {
+func prfPreMasterSecret(publicKey, privateKey []byte, curve NamedCurve) ([]byte, error) {
 	switch curve {
-	case namedCurveX25519:
+	case NamedCurveX25519:
 		var preMasterSecret, fixedWidthPrivateKey, fixedWidthPublicKey [32]byte
 		copy(fixedWidthPrivateKey[:], privateKey)
 		copy(fixedWidthPublicKey[:], publicKey)
 
 		curve25519.ScalarMult(&preMasterSecret, &fixedWidthPrivateKey, &fixedWidthPublicKey)
 		return preMasterSecret[:], nil
-	case namedCurveP256:
+	case NamedCurveP256:
 		x, y := elliptic.Unmarshal(elliptic.P256(), publicKey)
 		if x == nil || y == nil {
 			return nil, errInvalidNamedCurve
@@ -76,6 +76,33 @@ func prfPreMasterSecret(publicKey, privateKey []byte, curve namedCurve) ([]byte,
 	return nil, errInvalidNamedCurve
 }
 
+// prfPSKPreMasterSecret builds the premaster secret of the PSK key exchanges
+//
+//	struct {
+//	    opaque other_secret<0..2^16-1>;
+//	    opaque psk<0..2^16-1>;
+//	};
+//
+// For plain PSK other_secret is a string of zeroes as long as the PSK, for
+// ECDHE_PSK it is the ECDH shared secret.
+// https://tools.ietf.org/html/rfc4279#section-2
+// https://tools.ietf.org/html/rfc5489#section-2
+func prfPSKPreMasterSecret(psk, otherSecret []byte) []byte {
+	if otherSecret == nil {
+		otherSecret = make([]byte, len(psk))
+	}
+
+	out := make([]byte, 2+len(otherSecret)+2+len(psk))
+
+	binary.BigEndian.PutUint16(out, uint16(len(otherSecret)))
+	offset := 2 + copy(out[2:], otherSecret)
+
+	binary.BigEndian.PutUint16(out[offset:], uint16(len(psk)))
+	copy(out[offset+2:], psk)
+
+	return out
+}
+
 //  This PRF with the SHA-256 hash function is used for all cipher suites
 //  defined in this document and in TLS documents published prior to this
 //  document when TLS 1.2 is negotiated.  New cipher suites MUST explicitly
@@ -133,6 +160,15 @@ func prfMasterSecret(preMasterSecret, clientRandom, serverRandom []byte, h hashF
 	return prfPHash(preMasterSecret, seed, 48, h)
 }
 
+// prfExtendedMasterSecret binds the master secret to the handshake that
+// created it, the session hash covers every handshake message up to and
+// including the ClientKeyExchange
+// https://tools.ietf.org/html/rfc7627#section-4
+func prfExtendedMasterSecret(preMasterSecret, sessionHash []byte, h hashFunc) ([]byte, error) {
+	seed := append([]byte(prfExtendedMasterSecretLabel), sessionHash...)
+	return prfPHash(preMasterSecret, seed, 48, h)
+}
+
 func prfEncryptionKeys(masterSecret, clientRandom, serverRandom []byte, prfMacLen, prfKeyLen, prfIvLen int, h hashFunc) (*encryptionKeys, error) {
 	seed := append(append([]byte(prfKeyExpansionLabel), serverRandom...), clientRandom...)
 	keyMaterial, err := prfPHash(masterSecret, seed, (2*prfMacLen)+(2*prfKeyLen)+(2*prfIvLen), h)
@@ -186,9 +222,9 @@ func prfVerifyDataServer(masterSecret, handshakeBodies []byte, h hashFunc) ([]by
 	return prfVerifyData(masterSecret, handshakeBodies, prfVerifyDataServerLabel, h)
 }
 
-// compute the MAC using HMAC-SHA1
-func prfMac(epoch uint16, sequenceNumber uint64, contentType contentType, protocolVersion protocolVersion, cidLen int, cid []byte, payload []byte, key []byte) ([]byte, error) {
-	h := hmac.New(sha1.New, key)
+// compute the MAC using HMAC with the cipherSuite's hash (SHA1 or SHA256)
+func prfMac(epoch uint16, sequenceNumber uint64, contentType contentType, protocolVersion protocolVersion, cidLen int, cid []byte, payload []byte, key []byte, hf hashFunc) ([]byte, error) {
+	h := hmac.New(hf, key)
 
 	hasValidCid := (cid != nil && len(cid) >= cidLen && cidLen > 0)
 
@@ -12,7 +12,7 @@ func TestPreMasterSecret(t *testing.T) {
 	publicKey := []byte{0x9f, 0xd7, 0xad, 0x6d, 0xcf, 0xf4, 0x29, 0x8d, 0xd3, 0xf9, 0x6d, 0x5b, 0x1b, 0x2a, 0xf9, 0x10, 0xa0, 0x53, 0x5b, 0x14, 0x88, 0xd7, 0xf8, 0xfa, 0xbb, 0x34, 0x9a, 0x98, 0x28, 0x80, 0xb6, 0x15}
 	expectedPreMasterSecret := []byte{0xdf, 0x4a, 0x29, 0x1b, 0xaa, 0x1e, 0xb7, 0xcf, 0xa6, 0x93, 0x4b, 0x29, 0xb4, 0x74, 0xba, 0xad, 0x26, 0x97, 0xe2, 0x9f, 0x1f, 0x92, 0x0d, 0xcc, 0x77, 0xc8, 0xa0, 0xa0, 0x88, 0x44, 0x76, 0x24}
 
-	preMasterSecret, err := prfPreMasterSecret(publicKey, privateKey, namedCurveX25519)
+	preMasterSecret, err := prfPreMasterSecret(publicKey, privateKey, NamedCurveX25519)
 	if err != nil {
 		t.Fatal(err)
 	} else if !bytes.Equal(expectedPreMasterSecret, preMasterSecret) {
@@ -20,6 +20,22 @@ func TestPreMasterSecret(t *testing.T) {
 	}
 }
 
+func TestPSKPreMasterSecret(t *testing.T) {
+	psk := []byte{0xAB, 0xC1, 0x23}
+	expectedPreMasterSecret := []byte{0x00, 0x03, 0x00, 0x00, 0x00, 0x00, 0x03, 0xAB, 0xC1, 0x23}
+
+	if preMasterSecret := prfPSKPreMasterSecret(psk, nil); !bytes.Equal(expectedPreMasterSecret, preMasterSecret) {
+		t.Fatalf("PSK PremasterSecret exp: % 02x actual: % 02x", expectedPreMasterSecret, preMasterSecret)
+	}
+
+	otherSecret := []byte{0x01, 0x02}
+	expectedPreMasterSecret = []byte{0x00, 0x02, 0x01, 0x02, 0x00, 0x03, 0xAB, 0xC1, 0x23}
+
+	if preMasterSecret := prfPSKPreMasterSecret(psk, otherSecret); !bytes.Equal(expectedPreMasterSecret, preMasterSecret) {
+		t.Fatalf("ECDHE_PSK PremasterSecret exp: % 02x actual: % 02x", expectedPreMasterSecret, preMasterSecret)
+	}
+}
+
 func TestMasterSecret(t *testing.T) {
 	preMasterSecret := []byte{0xdf, 0x4a, 0x29, 0x1b, 0xaa, 0x1e, 0xb7, 0xcf, 0xa6, 0x93, 0x4b, 0x29, 0xb4, 0x74, 0xba, 0xad, 0x26, 0x97, 0xe2, 0x9f, 0x1f, 0x92, 0x0d, 0xcc, 0x77, 0xc8, 0xa0, 0xa0, 0x88, 0x44, 0x76, 0x24}
 	clientRandom := []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f}
@@ -34,6 +50,19 @@ func TestMasterSecret(t *testing.T) {
 	}
 }
 
+func TestExtendedMasterSecret(t *testing.T) {
+	preMasterSecret := []byte{0xdf, 0x4a, 0x29, 0x1b, 0xaa, 0x1e, 0xb7, 0xcf, 0xa6, 0x93, 0x4b, 0x29, 0xb4, 0x74, 0xba, 0xad, 0x26, 0x97, 0xe2, 0x9f, 0x1f, 0x92, 0x0d, 0xcc, 0x77, 0xc8, 0xa0, 0xa0, 0x88, 0x44, 0x76, 0x24}
+	sessionHash := bytes.Repeat([]byte{0xaa}, 32)
+	expectedMasterSecret := []byte{0x4d, 0x26, 0x8b, 0x93, 0x54, 0xec, 0x9a, 0xf8, 0xe7, 0x41, 0xfb, 0x62, 0x10, 0x3b, 0xb7, 0x7b, 0x79, 0xe3, 0xdd, 0x18, 0xc6, 0x23, 0x83, 0xdb, 0x52, 0xe3, 0x6d, 0x9e, 0x6c, 0x9b, 0xe8, 0x99, 0xef, 0xf2, 0x57, 0x74, 0x8a, 0xc5, 0x04, 0x42, 0x8a, 0x02, 0xa1, 0x81, 0x4a, 0x81, 0xce, 0x37}
+
+	masterSecret, err := prfExtendedMasterSecret(preMasterSecret, sessionHash, sha256.New)
+	if err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(expectedMasterSecret, masterSecret) {
+		t.Fatalf("extendedMasterSecret exp: % 02x actual: % 02x", expectedMasterSecret, masterSecret)
+	}
+}
+
 func TestEncryptionKeys(t *testing.T) {
 	clientRandom := []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f}
 	serverRandom := []byte{0x70, 0x71, 0x72, 0x73, 0x74, 0x75, 0x76, 0x77, 0x78, 0x79, 0x7a, 0x7b, 0x7c, 0x7d, 0x7e, 0x7f, 0x80, 0x81, 0x82, 0x83, 0x84, 0x85, 0x86, 0x87, 0x88, 0x89, 0x8a, 0x8b, 0x8c, 0x8d, 0x8e, 0x8f}
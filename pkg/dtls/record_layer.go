@@ -91,7 +91,14 @@ func unpackDatagram(buf []byte) ([][]byte, error) {
 			hlen += extensionConnectionIdSize
 		}
 
+		if len(buf)-offset < hlen {
+			return nil, errDTLSPacketInvalidLength
+		}
+
 		pktLen := (hlen + int(binary.BigEndian.Uint16(buf[offset+plenOffset:])))
+		if len(buf)-offset < pktLen {
+			return nil, errDTLSPacketInvalidLength
+		}
 		out = append(out, buf[offset:offset+pktLen])
 		offset += pktLen
 	}
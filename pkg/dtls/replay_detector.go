@@ -0,0 +1,84 @@
+package dtls
+
+const defaultReplayProtectionWindow = 64
+
+// replayDetector is the sliding window of RFC 6347 that discards
+// records received before. Sequence numbers restart with every epoch, so
+// the window is reset when a new epoch starts.
+// https://tools.ietf.org/html/rfc6347#section-4.1.2.6
+type replayDetector struct {
+	size   uint64
+	mask   []uint64 // bit seq % size is set if seq was received
+	epoch  uint16
+	latest uint64 // highest sequence number received in epoch
+	empty  bool   // nothing was received in epoch yet
+}
+
+// newReplayDetector returns a window of size records, rounded up to a
+// multiple of 64
+func newReplayDetector(size int) *replayDetector {
+	words := (size + 63) / 64
+	return &replayDetector{
+		size:  uint64(words * 64),
+		mask:  make([]uint64, words),
+		empty: true,
+	}
+}
+
+// check reports whether the record epoch/seq is new. A record is too old
+// if it is left of the window, and a duplicate if it was accepted before.
+func (r *replayDetector) check(epoch uint16, seq uint64) (tooOld, duplicate bool) {
+	switch {
+	case epoch < r.epoch:
+		return true, false
+	case epoch > r.epoch || r.empty || seq > r.latest:
+		return false, false
+	case r.latest-seq >= r.size:
+		return true, false
+	}
+	return false, r.mask[(seq%r.size)/64]&(1<<(seq%64)) != 0
+}
+
+// accept marks epoch/seq as received, it must only be called for records
+// that passed check and were authenticated
+func (r *replayDetector) accept(epoch uint16, seq uint64) {
+	if epoch > r.epoch {
+		r.epoch = epoch
+		r.empty = true
+	}
+
+	if r.empty {
+		r.clear()
+		r.latest = seq
+		r.empty = false
+	} else if seq > r.latest {
+		// Slide the window, forgetting the sequence numbers it leaves
+		if seq-r.latest >= r.size {
+			r.clear()
+		} else {
+			for s := r.latest + 1; s < seq; s++ {
+				r.mask[(s%r.size)/64] &^= 1 << (s % 64)
+			}
+		}
+		r.latest = seq
+	}
+
+	r.mask[(seq%r.size)/64] |= 1 << (seq % 64)
+}
+
+func (r *replayDetector) clear() {
+	for i := range r.mask {
+		r.mask[i] = 0
+	}
+}
+
+// ReplayStats counts the records a Conn discarded because of the
+// anti-replay window
+type ReplayStats struct {
+	// TooOld records were left of the window, they may or may not have
+	// been received before
+	TooOld uint64
+
+	// Duplicate records were received before
+	Duplicate uint64
+}
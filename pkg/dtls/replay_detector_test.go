@@ -0,0 +1,97 @@
+package dtls
+
+import "testing"
+
+func TestReplayDetector(t *testing.T) {
+	type record struct {
+		epoch     uint16
+		seq       uint64
+		tooOld    bool
+		duplicate bool
+	}
+
+	for _, test := range []struct {
+		name    string
+		size    int
+		records []record
+	}{
+		{
+			name: "in order",
+			size: 64,
+			records: []record{
+				{1, 0, false, false},
+				{1, 1, false, false},
+				{1, 2, false, false},
+				{1, 1, false, true},
+			},
+		},
+		{
+			name: "reordered inside the window",
+			size: 64,
+			records: []record{
+				{1, 10, false, false},
+				{1, 3, false, false},
+				{1, 7, false, false},
+				{1, 3, false, true},
+				{1, 10, false, true},
+				{1, 5, false, false},
+			},
+		},
+		{
+			name: "left of the window",
+			size: 64,
+			records: []record{
+				{1, 100, false, false},
+				{1, 37, false, false},
+				{1, 36, true, false},
+				{1, 0, true, false},
+			},
+		},
+		{
+			name: "slide forgets old records",
+			size: 64,
+			records: []record{
+				{1, 0, false, false},
+				{1, 64, false, false},
+				{1, 65, false, false},
+				{1, 2, false, false},
+				{1, 1, true, false},
+				{1, 200, false, false},
+				{1, 137, false, false},
+				{1, 65, true, false},
+			},
+		},
+		{
+			name: "size rounded up",
+			size: 100,
+			records: []record{
+				{1, 200, false, false},
+				{1, 73, false, false},
+				{1, 72, true, false},
+			},
+		},
+		{
+			name: "new epoch resets the window",
+			size: 64,
+			records: []record{
+				{1, 5, false, false},
+				{2, 5, false, false},
+				{2, 0, false, false},
+				{2, 5, false, true},
+				{1, 6, true, false},
+			},
+		},
+	} {
+		r := newReplayDetector(test.size)
+		for i, rec := range test.records {
+			tooOld, duplicate := r.check(rec.epoch, rec.seq)
+			if tooOld != rec.tooOld || duplicate != rec.duplicate {
+				t.Errorf("replayDetector %s record %d: got tooOld %t duplicate %t, want %t %t",
+					test.name, i, tooOld, duplicate, rec.tooOld, rec.duplicate)
+			}
+			if !tooOld && !duplicate {
+				r.accept(rec.epoch, rec.seq)
+			}
+		}
+	}
+}
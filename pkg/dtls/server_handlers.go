@@ -3,7 +3,9 @@ package dtls
 import (
 	"bytes"
 	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"time"
 )
 
 func serverExcludeRules() map[flightVal]handshakeCacheExcludeRule {
@@ -21,15 +23,30 @@ func serverHandshakeHandler(c *Conn) error {
 
 	for out, fragEpoch := c.fragmentBuffer.pop(); out != nil; out, fragEpoch = c.fragmentBuffer.pop() {
 		rawHandshake := &handshake{}
+		if c.cipherSuite != nil {
+			rawHandshake.keyExchangeAlgorithm = c.cipherSuite.keyExchangeAlgorithm()
+		}
 		if err := rawHandshake.Unmarshal(out); err != nil {
 			return err
 		}
-		c.handshakeCache.push(out, fragEpoch, rawHandshake.handshakeHeader.messageSequence /* isLocal */, false, c.currFlight.get())
+
+		// The first ClientHello is part of the handshake if the HelloVerifyRequest
+		// is skipped, or if a Listener already sent it and this one carries a
+		// valid cookie. It is then cached like the one that follows our own
+		// HelloVerifyRequest.
+		currFlight := c.currFlight.get()
+		verifiedHello := false
+		if h, ok := rawHandshake.handshakeMessage.(*handshakeMessageClientHello); ok && currFlight == flight0 {
+			if verifiedHello = c.skipHelloVerify || c.cookieVerifier.VerifyCookie(c.nextConn.RemoteAddr(), cookieParams(h), h.cookie); verifiedHello {
+				currFlight = flight2
+			}
+		}
+		c.handshakeCache.push(out, fragEpoch, rawHandshake.handshakeHeader.messageSequence /* isLocal */, false, currFlight)
 
 		switch h := rawHandshake.handshakeMessage.(type) {
 		case *handshakeMessageClientHello:
 			if c.currFlight.get() == flight2 {
-				if !bytes.Equal(c.cookie, h.cookie) {
+				if !c.cookieVerifier.VerifyCookie(c.nextConn.RemoteAddr(), cookieParams(h), h.cookie) {
 					return errCookieMismatch
 				}
 				c.localSequenceNumber = 1
@@ -41,17 +58,26 @@ func serverHandshakeHandler(c *Conn) error {
 
 			c.remoteRandom = h.random
 
-			if len(h.cipherSuites) == 0 {
-				return errCipherSuiteNoIntersection
+			cipherSuite, err := serverSelectCipherSuite(h.cipherSuites, c.localCipherSuites)
+			if err != nil {
+				return err
+			}
+			c.cipherSuite = cipherSuite
+
+			// A resumed session keeps the cipherSuite it was negotiated with
+			if session, sessionCipherSuite := serverSessionToResume(c, h); session != nil {
+				c.session = session
+				c.cipherSuite = sessionCipherSuite
 			}
-			c.cipherSuite = h.cipherSuites[0] // TODO assert we support (No RSA)
 
 			for _, extension := range h.extensions {
 				switch e := extension.(type) {
 				case *extensionSupportedEllipticCurves:
 					c.namedCurve = e.ellipticCurves[0]
 				case *extensionUseSRTP:
-					// TODO expose to API
+					if profile, ok := selectSRTPProtectionProfile(c.localSRTPProfiles, e.protectionProfiles); ok {
+						c.srtpProtectionProfile = profile
+					}
 				case *extensionConnectionId:
 					if len(e.connectionId) > 0 {
 						c.ccid = e.connectionId
@@ -60,17 +86,67 @@ func serverHandshakeHandler(c *Conn) error {
 					tmp := make([]byte, extensionConnectionIdSize)
 					rand.Read(tmp)
 					c.scid = tmp
+				case *extensionExtendedMasterSecret:
+					if c.localExtendedMasterSecret != DisableExtendedMasterSecret {
+						c.extendedMasterSecret = true
+					}
+				case *extensionEncryptThenMac:
+					if cbc, ok := c.cipherSuite.(encryptThenMacCipherSuite); ok {
+						cbc.setEncryptThenMac()
+						c.encryptThenMac = true
+					}
+				case *extensionSessionTicket:
+					if len(c.sessionTicketKeys) != 0 {
+						c.sendSessionTicket = true
+					}
 				}
 			}
 
-			if c.localKeypair == nil {
-				var err error
-				c.localKeypair, err = generateKeypair(c.namedCurve)
-				if err != nil {
+			if c.localExtendedMasterSecret == RequireExtendedMasterSecret && !c.extendedMasterSecret {
+				return errServerRequiredButNoClientEMS
+			}
+
+			// Sessions are only resumed with the same master secret derivation,
+			// otherwise a full handshake is done
+			// https://tools.ietf.org/html/rfc7627#section-5.3
+			if c.session != nil && c.session.ExtendedMasterSecret == c.extendedMasterSecret {
+				if err := serverResumeSession(c); err != nil {
+					return err
+				}
+
+				// The client keeps using the ticket it has, new ones are only
+				// issued by full handshakes
+				c.sendSessionTicket = false
+			} else {
+				if c.localKeypair == nil && c.cipherSuite.keyExchangeAlgorithm().isECDHE() {
+					c.localKeypair, err = generateKeypair(c.namedCurve)
+					if err != nil {
+						return err
+					}
+				}
+
+				if c.sessionCache != nil && len(c.sessionID) == 0 {
+					c.sessionID = make([]byte, sessionIDLength)
+					if _, err := rand.Read(c.sessionID); err != nil {
+						return err
+					}
+				}
+			}
+
+			if verifiedHello {
+				// Without a HelloVerifyRequest the ServerHello is our first message
+				if !c.skipHelloVerify {
+					c.localSequenceNumber = 1
+				}
+				if err := c.currFlight.set(flight4); err != nil {
 					return err
 				}
+				break
 			}
 
+			if c.cookie, err = c.cookieGenerator.GenerateCookie(c.nextConn.RemoteAddr(), cookieParams(h)); err != nil {
+				return err
+			}
 			if err := c.currFlight.set(flight2); err != nil {
 				return err
 			}
@@ -82,8 +158,6 @@ func serverHandshakeHandler(c *Conn) error {
 
 		case *handshakeMessageClientKeyExchange:
 			if c.currFlight.get() == flight4 {
-				c.remoteKeypair = &namedCurveKeypair{c.namedCurve, h.publicKey, nil}
-
 				serverRandom, err := c.localRandom.Marshal()
 				if err != nil {
 					return err
@@ -93,16 +167,41 @@ func serverHandshakeHandler(c *Conn) error {
 					return err
 				}
 
-				preMasterSecret, err := prfPreMasterSecret(c.remoteKeypair.publicKey, c.localKeypair.privateKey, c.localKeypair.curve)
-				if err != nil {
-					return err
+				var preMasterSecret []byte
+				if c.cipherSuite.keyExchangeAlgorithm().isECDHE() {
+					c.remoteKeypair = &namedCurveKeypair{c.namedCurve, h.publicKey, nil}
+
+					preMasterSecret, err = prfPreMasterSecret(c.remoteKeypair.publicKey, c.localKeypair.privateKey, c.localKeypair.curve)
+					if err != nil {
+						return err
+					}
 				}
+				if c.cipherSuite.keyExchangeAlgorithm().isPSK() {
+					psk, err := c.localPSKCallback(h.identity)
+					if err != nil {
+						return err
+					}
+					preMasterSecret = prfPSKPreMasterSecret(psk, preMasterSecret)
+				}
+
+				if c.extendedMasterSecret {
+					var sessionHash []byte
+					sessionHash, err = c.handshakeCache.sessionHash(c.cipherSuite.hashFunc(), serverExcludeRules())
+					if err != nil {
+						return err
+					}
 
-				c.masterSecret, err = prfMasterSecret(preMasterSecret, clientRandom, serverRandom, c.cipherSuite.hashFunc())
+					c.masterSecret, err = prfExtendedMasterSecret(preMasterSecret, sessionHash, c.cipherSuite.hashFunc())
+				} else {
+					c.masterSecret, err = prfMasterSecret(preMasterSecret, clientRandom, serverRandom, c.cipherSuite.hashFunc())
+				}
 				if err != nil {
 					return err
 				}
 
+				if err := c.writeKeyLog(clientRandom, c.masterSecret); err != nil {
+					return err
+				}
 				if err := c.cipherSuite.init(c.masterSecret, clientRandom, serverRandom /* isClient */, false); err != nil {
 					return err
 				}
@@ -117,7 +216,37 @@ func serverHandshakeHandler(c *Conn) error {
 					return errVerifyDataMismatch
 				}
 				c.localEpoch = 1
-				c.localSequenceNumber = 5
+
+				if c.resumed {
+					// Abbreviated handshake, our Finished took sequenceNumber 0
+					// of the new epoch and the client's Finished ends it
+					if c.scid != nil {
+						if err := c.PromoteToCidConnection(c.scid); err != nil {
+							return err
+						}
+					}
+					c.signalHandshakeComplete()
+					break
+				}
+
+				if c.sessionCache != nil && len(c.sessionID) != 0 {
+					c.sessionCache.Put(serverSessionKey(c.sessionID), newSession(c))
+				}
+				if c.sendSessionTicket {
+					c.sessionTicket, err = encryptSessionTicket(c.sessionTicketKeys, newSession(c), time.Now())
+					if err != nil {
+						return err
+					}
+				}
+
+				// Flight6 follows our flight4, which started at the ServerHello
+				c.localSequenceNumber += 2 // ServerHello, ServerHelloDone
+				if serverSendsCertificate(c) {
+					c.localSequenceNumber++
+				}
+				if serverSendsKeyExchange(c) {
+					c.localSequenceNumber++
+				}
 				if err := c.currFlight.set(flight6); err != nil {
 					return err
 				}
@@ -166,16 +295,40 @@ func serverFlightHandler(c *Conn) (bool, error) {
 		serverHello := handshakeMessageServerHello{
 			version:           protocolVersion1_2,
 			random:            c.localRandom,
+			sessionID:         c.sessionID,
 			cipherSuite:       c.cipherSuite,
 			compressionMethod: defaultCompressionMethods[0],
-			extensions: []extension{
+			extensions:        []extension{},
+		}
+
+		// The EC extensions are unsolicited if the client only offered PSK
+		if c.cipherSuite.keyExchangeAlgorithm().isECDHE() {
+			serverHello.extensions = append(serverHello.extensions,
 				&extensionSupportedEllipticCurves{
-					ellipticCurves: []namedCurve{namedCurveX25519, namedCurveP256},
+					ellipticCurves: []NamedCurve{NamedCurveX25519, NamedCurveP256},
 				},
 				&extensionSupportedPointFormats{
 					pointFormats: []ellipticCurvePointFormat{ellipticCurvePointFormatUncompressed},
 				},
-			},
+			)
+		}
+
+		if c.extendedMasterSecret {
+			serverHello.extensions = append(serverHello.extensions, &extensionExtendedMasterSecret{})
+		}
+
+		if c.encryptThenMac {
+			serverHello.extensions = append(serverHello.extensions, &extensionEncryptThenMac{})
+		}
+
+		if c.sendSessionTicket {
+			serverHello.extensions = append(serverHello.extensions, &extensionSessionTicket{})
+		}
+
+		if c.srtpProtectionProfile != 0 {
+			serverHello.extensions = append(serverHello.extensions, &extensionUseSRTP{
+				protectionProfiles: []SRTPProtectionProfile{c.srtpProtectionProfile},
+			})
 		}
 
 		if c.scid != nil {
@@ -198,66 +351,95 @@ func serverFlightHandler(c *Conn) (bool, error) {
 			},
 		}, false)
 
-		c.internalSend(&recordLayer{
-			recordLayerHeader: recordLayerHeader{
-				sequenceNumber:  c.localSequenceNumber + 1,
-				protocolVersion: protocolVersion1_2,
-			},
-			content: &handshake{
-				// sequenceNumber and messageSequence line up, may need to be re-evaluated
-				handshakeHeader: handshakeHeader{
-					messageSequence: uint16(c.localSequenceNumber + 1),
-				},
-				handshakeMessage: &handshakeMessageCertificate{
-					certificate: c.localCertificate,
-				}},
-		}, false)
-
-		serverRandom, err := c.localRandom.Marshal()
-		if err != nil {
-			return false, err
-		}
-		clientRandom, err := c.remoteRandom.Marshal()
-		if err != nil {
+		if c.resumed {
+			// Abbreviated handshake, the keys are known so we finish first
+			err := serverSendFinished(c, c.localSequenceNumber+1)
+			c.lock.RUnlock()
 			return false, err
 		}
 
-		signature, err := generateKeySignature(clientRandom, serverRandom, c.localKeypair.publicKey, c.namedCurve, c.localPrivateKey, HashAlgorithmSHA256)
-		if err != nil {
-			return false, err
+		sequenceNumber := c.localSequenceNumber + 1
+		if serverSendsCertificate(c) {
+			c.internalSend(&recordLayer{
+				recordLayerHeader: recordLayerHeader{
+					sequenceNumber:  sequenceNumber,
+					protocolVersion: protocolVersion1_2,
+				},
+				content: &handshake{
+					// sequenceNumber and messageSequence line up, may need to be re-evaluated
+					handshakeHeader: handshakeHeader{
+						messageSequence: uint16(sequenceNumber),
+					},
+					handshakeMessage: &handshakeMessageCertificate{
+						certificate: c.localCertificate,
+					}},
+			}, false)
+			sequenceNumber++
 		}
 
-		c.internalSend(&recordLayer{
-			recordLayerHeader: recordLayerHeader{
-				sequenceNumber:  c.localSequenceNumber + 2,
-				protocolVersion: protocolVersion1_2,
-			},
-			content: &handshake{
-				// sequenceNumber and messageSequence line up, may need to be re-evaluated
-				handshakeHeader: handshakeHeader{
-					messageSequence: uint16(c.localSequenceNumber + 2),
+		if serverSendsKeyExchange(c) {
+			serverKeyExchange := &handshakeMessageServerKeyExchange{
+				keyExchangeAlgorithm: c.cipherSuite.keyExchangeAlgorithm(),
+				identityHint:         c.localPSKIdentityHint,
+			}
+
+			if c.cipherSuite.keyExchangeAlgorithm().isECDHE() {
+				serverKeyExchange.ellipticCurveType = ellipticCurveTypeNamedCurve
+				serverKeyExchange.namedCurve = c.namedCurve
+				serverKeyExchange.publicKey = c.localKeypair.publicKey
+			}
+
+			// ECDHE_PSK is authenticated by the PSK, only ECDHE is signed
+			if c.cipherSuite.keyExchangeAlgorithm() == keyExchangeAlgorithmEcdhe {
+				serverRandom, err := c.localRandom.Marshal()
+				if err != nil {
+					c.lock.RUnlock()
+					return false, err
+				}
+				clientRandom, err := c.remoteRandom.Marshal()
+				if err != nil {
+					c.lock.RUnlock()
+					return false, err
+				}
+
+				signature, err := generateKeySignature(clientRandom, serverRandom, c.localKeypair.publicKey, c.namedCurve, c.localPrivateKey, c.cipherSuite.hashAlgorithm())
+				if err != nil {
+					c.lock.RUnlock()
+					return false, err
+				}
+
+				serverKeyExchange.hashAlgorithm = c.cipherSuite.hashAlgorithm()
+				serverKeyExchange.signatureAlgorithm = signatureAlgorithmECDSA
+				serverKeyExchange.signature = signature
+			}
+
+			c.internalSend(&recordLayer{
+				recordLayerHeader: recordLayerHeader{
+					sequenceNumber:  sequenceNumber,
+					protocolVersion: protocolVersion1_2,
 				},
-				handshakeMessage: &handshakeMessageServerKeyExchange{
-					ellipticCurveType:  ellipticCurveTypeNamedCurve,
-					namedCurve:         c.namedCurve,
-					publicKey:          c.localKeypair.publicKey,
-					hashAlgorithm:      HashAlgorithmSHA256,
-					signatureAlgorithm: signatureAlgorithmECDSA,
-					signature:          signature,
-				}},
-		}, false)
+				content: &handshake{
+					// sequenceNumber and messageSequence line up, may need to be re-evaluated
+					handshakeHeader: handshakeHeader{
+						messageSequence: uint16(sequenceNumber),
+					},
+					handshakeMessage: serverKeyExchange,
+				},
+			}, false)
+			sequenceNumber++
+		}
 
 		// TODO: CertificateRequest
 
 		c.internalSend(&recordLayer{
 			recordLayerHeader: recordLayerHeader{
-				sequenceNumber:  c.localSequenceNumber + 3,
+				sequenceNumber:  sequenceNumber,
 				protocolVersion: protocolVersion1_2,
 			},
 			content: &handshake{
 				// sequenceNumber and messageSequence line up, may need to be re-evaluated
 				handshakeHeader: handshakeHeader{
-					messageSequence: uint16(c.localSequenceNumber + 3),
+					messageSequence: uint16(sequenceNumber),
 				},
 				handshakeMessage: &handshakeMessageServerHelloDone{},
 			},
@@ -267,39 +449,32 @@ func serverFlightHandler(c *Conn) (bool, error) {
 
 	case flight6:
 		c.lock.RLock()
-		c.internalSend(&recordLayer{
-			recordLayerHeader: recordLayerHeader{
-				sequenceNumber:  c.localSequenceNumber,
-				protocolVersion: protocolVersion1_2,
-			},
-			content: &changeCipherSpec{},
-		}, false)
-
-		if len(c.localVerifyData) == 0 {
-			var err error
-			c.localVerifyData, err = prfVerifyDataServer(c.masterSecret, c.handshakeCache.combinedHandshake(serverExcludeRules(), false), c.cipherSuite.hashFunc())
-			if err != nil {
-				return false, err
-			}
-		}
-
-		c.internalSend(&recordLayer{
-			recordLayerHeader: recordLayerHeader{
-				epoch:           1,
-				sequenceNumber:  0, // sequenceNumber restarts per epoch
-				protocolVersion: protocolVersion1_2,
-			},
-			content: &handshake{
-				// sequenceNumber and messageSequence line up, may need to be re-evaluated
-				handshakeHeader: handshakeHeader{
-					messageSequence: uint16(c.localSequenceNumber), // KeyExchange + 1
+		sequenceNumber := c.localSequenceNumber
+		if c.sendSessionTicket {
+			c.internalSend(&recordLayer{
+				recordLayerHeader: recordLayerHeader{
+					sequenceNumber:  sequenceNumber,
+					protocolVersion: protocolVersion1_2,
+				},
+				content: &handshake{
+					// sequenceNumber and messageSequence line up, may need to be re-evaluated
+					handshakeHeader: handshakeHeader{
+						messageSequence: uint16(sequenceNumber),
+					},
+					handshakeMessage: &handshakeMessageNewSessionTicket{
+						ticketLifetimeHint: uint32(sessionTicketLifetime / time.Second),
+						ticket:             c.sessionTicket,
+					},
 				},
+			}, false)
+			sequenceNumber++
+		}
 
-				handshakeMessage: &handshakeMessageFinished{
-					verifyData: c.localVerifyData,
-				}},
-		}, true)
+		err := serverSendFinished(c, sequenceNumber)
 		c.lock.RUnlock()
+		if err != nil {
+			return false, err
+		}
 
 		// TODO: Better way to end handshake
 		c.signalHandshakeComplete()
@@ -309,3 +484,124 @@ func serverFlightHandler(c *Conn) (bool, error) {
 	}
 	return false, nil
 }
+
+// The Certificate is only sent when the cipherSuite is authenticated with one
+func serverSendsCertificate(c *Conn) bool {
+	return !c.cipherSuite.keyExchangeAlgorithm().isPSK()
+}
+
+// For PSK the ServerKeyExchange only carries the identity hint, and is omitted
+// without one. https://tools.ietf.org/html/rfc4279#section-2
+func serverSendsKeyExchange(c *Conn) bool {
+	return c.cipherSuite.keyExchangeAlgorithm() != keyExchangeAlgorithmPsk || len(c.localPSKIdentityHint) != 0
+}
+
+// serverSendFinished sends the ChangeCipherSpec and Finished that end a
+// server flight, sequenceNumber is the one of the ChangeCipherSpec
+func serverSendFinished(c *Conn, sequenceNumber uint64) error {
+	c.internalSend(&recordLayer{
+		recordLayerHeader: recordLayerHeader{
+			sequenceNumber:  sequenceNumber,
+			protocolVersion: protocolVersion1_2,
+		},
+		content: &changeCipherSpec{},
+	}, false)
+
+	if len(c.localVerifyData) == 0 {
+		var err error
+		c.localVerifyData, err = prfVerifyDataServer(c.masterSecret, c.handshakeCache.combinedHandshake(serverExcludeRules(), false), c.cipherSuite.hashFunc())
+		if err != nil {
+			return err
+		}
+	}
+
+	c.internalSend(&recordLayer{
+		recordLayerHeader: recordLayerHeader{
+			epoch:           1,
+			sequenceNumber:  0, // sequenceNumber restarts per epoch
+			protocolVersion: protocolVersion1_2,
+		},
+		content: &handshake{
+			// sequenceNumber and messageSequence line up, may need to be re-evaluated
+			handshakeHeader: handshakeHeader{
+				messageSequence: uint16(sequenceNumber),
+			},
+
+			handshakeMessage: &handshakeMessageFinished{
+				verifyData: c.localVerifyData,
+			}},
+	}, true)
+	return nil
+}
+
+// serverSessionKey identifies a Session in the SessionCache
+func serverSessionKey(sessionID []byte) string {
+	return hex.EncodeToString(sessionID)
+}
+
+// serverSessionToResume returns the Session the client offered to resume, if
+// it is known and its cipherSuite is still acceptable to both sides
+func serverSessionToResume(c *Conn, h *handshakeMessageClientHello) (*Session, cipherSuite) {
+	session := serverLookupSession(c, h)
+	if session == nil {
+		return nil, nil
+	}
+
+	for _, l := range c.localCipherSuites {
+		if l.ID() != session.CipherSuiteID {
+			continue
+		}
+		if cipherSuite, err := serverSelectCipherSuite(h.cipherSuites, []cipherSuite{l}); err == nil {
+			return session, cipherSuite
+		}
+	}
+	return nil, nil
+}
+
+// serverLookupSession opens the session ticket the client offered, or looks
+// its session ID up in the SessionCache
+func serverLookupSession(c *Conn, h *handshakeMessageClientHello) *Session {
+	if len(c.sessionTicketKeys) != 0 {
+		for _, extension := range h.extensions {
+			e, ok := extension.(*extensionSessionTicket)
+			if !ok || len(e.ticket) == 0 {
+				continue
+			}
+
+			if session, ok := decryptSessionTicket(c.sessionTicketKeys, e.ticket, time.Now()); ok {
+				// The client recognises the resumption by its session ID
+				// being echoed https://tools.ietf.org/html/rfc5077#section-3.4
+				session.ID = h.sessionID
+				return session
+			}
+		}
+	}
+
+	if c.sessionCache != nil && len(h.sessionID) != 0 {
+		if session, ok := c.sessionCache.Get(serverSessionKey(h.sessionID)); ok {
+			return session
+		}
+	}
+	return nil
+}
+
+// serverResumeSession restores the master secret of the Session the client
+// offered, the abbreviated handshake needs no key exchange
+func serverResumeSession(c *Conn) error {
+	c.resumed = true
+	c.sessionID = c.session.ID
+	c.masterSecret = c.session.Secret
+
+	serverRandom, err := c.localRandom.Marshal()
+	if err != nil {
+		return err
+	}
+	clientRandom, err := c.remoteRandom.Marshal()
+	if err != nil {
+		return err
+	}
+	if err = c.writeKeyLog(clientRandom, c.masterSecret); err != nil {
+		return err
+	}
+	return c.cipherSuite.init(c.masterSecret, clientRandom, serverRandom /* isClient */, false)
+}
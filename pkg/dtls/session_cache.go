@@ -0,0 +1,116 @@
+package dtls
+
+import (
+	"container/list"
+	"sync"
+)
+
+const defaultSessionCacheCapacity = 64
+
+// sessionIDLength is the length of the session IDs generated by a server
+const sessionIDLength = 32
+
+// Session holds the state needed to resume a DTLS session with an
+// abbreviated handshake. https://tools.ietf.org/html/rfc5246#section-7.3
+type Session struct {
+	// ID is the session ID the server assigned to the session
+	ID []byte
+
+	// Secret is the master secret of the session
+	Secret []byte
+
+	// CipherSuiteID is the cipher suite the session was negotiated with,
+	// a resumed connection must use the same one
+	CipherSuiteID CipherSuiteID
+
+	// ExtendedMasterSecret is set if Secret was derived with the
+	// Extended Master Secret (RFC 7627)
+	ExtendedMasterSecret bool
+
+	// Ticket is the session ticket (RFC 5077) the server issued for the
+	// session, if any. It is only set on the client side.
+	Ticket []byte
+}
+
+// newSession captures the state of an established connection so that it
+// can be resumed
+func newSession(c *Conn) *Session {
+	return &Session{
+		ID:                   append([]byte{}, c.sessionID...),
+		Secret:               append([]byte{}, c.masterSecret...),
+		CipherSuiteID:        c.cipherSuite.ID(),
+		ExtendedMasterSecret: c.extendedMasterSecret,
+	}
+}
+
+// SessionCache stores Sessions so that they can be resumed. A client
+// looks up sessions by the address of the server, a server by the hex
+// encoded session ID. Implementations must be safe for concurrent use,
+// a SessionCache may be shared by many connections.
+type SessionCache interface {
+	// Get returns the Session stored for key, if any
+	Get(key string) (*Session, bool)
+
+	// Put stores a Session for key, replacing the previous one
+	Put(key string, session *Session)
+}
+
+type lruSessionCacheEntry struct {
+	key     string
+	session *Session
+}
+
+// lruSessionCache is a SessionCache that keeps the most recently used
+// Sessions in memory
+type lruSessionCache struct {
+	sync.Mutex
+
+	capacity int
+	order    *list.List // front is the most recently used
+	entries  map[string]*list.Element
+}
+
+// NewLRUSessionCache returns an in-memory SessionCache that holds up to
+// capacity Sessions, evicting the least recently used one when it is
+// full. If capacity is < 1 a default capacity is used instead.
+func NewLRUSessionCache(capacity int) SessionCache {
+	if capacity < 1 {
+		capacity = defaultSessionCacheCapacity
+	}
+
+	return &lruSessionCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  map[string]*list.Element{},
+	}
+}
+
+func (l *lruSessionCache) Get(key string) (*Session, bool) {
+	l.Lock()
+	defer l.Unlock()
+
+	e, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+	l.order.MoveToFront(e)
+	return e.Value.(*lruSessionCacheEntry).session, true
+}
+
+func (l *lruSessionCache) Put(key string, session *Session) {
+	l.Lock()
+	defer l.Unlock()
+
+	if e, ok := l.entries[key]; ok {
+		e.Value.(*lruSessionCacheEntry).session = session
+		l.order.MoveToFront(e)
+		return
+	}
+
+	if l.order.Len() >= l.capacity {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(*lruSessionCacheEntry).key)
+	}
+	l.entries[key] = l.order.PushFront(&lruSessionCacheEntry{key, session})
+}
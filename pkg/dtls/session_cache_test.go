@@ -0,0 +1,39 @@
+package dtls
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLRUSessionCache(t *testing.T) {
+	cache := NewLRUSessionCache(2)
+
+	a := &Session{ID: []byte{0x01}, Secret: []byte{0xAA}, CipherSuiteID: TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}
+	b := &Session{ID: []byte{0x02}, Secret: []byte{0xBB}, CipherSuiteID: TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}
+	c := &Session{ID: []byte{0x03}, Secret: []byte{0xCC}, CipherSuiteID: TLS_PSK_WITH_AES_128_CCM_8}
+
+	cache.Put("a", a)
+	cache.Put("b", b)
+
+	// a is now the most recently used, so adding c evicts b
+	if s, ok := cache.Get("a"); !ok || !reflect.DeepEqual(s, a) {
+		t.Errorf("lruSessionCache get a: got %#v, want %#v", s, a)
+	}
+	cache.Put("c", c)
+
+	if s, ok := cache.Get("b"); ok {
+		t.Errorf("lruSessionCache get b: got %#v, want evicted", s)
+	}
+	if s, ok := cache.Get("c"); !ok || !reflect.DeepEqual(s, c) {
+		t.Errorf("lruSessionCache get c: got %#v, want %#v", s, c)
+	}
+
+	// Replacing an entry must not evict anything
+	cache.Put("a", b)
+	if s, ok := cache.Get("a"); !ok || !reflect.DeepEqual(s, b) {
+		t.Errorf("lruSessionCache get a: got %#v, want %#v", s, b)
+	}
+	if s, ok := cache.Get("c"); !ok || !reflect.DeepEqual(s, c) {
+		t.Errorf("lruSessionCache get c: got %#v, want %#v", s, c)
+	}
+}
@@ -0,0 +1,118 @@
+package dtls
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/binary"
+	"time"
+)
+
+const (
+	sessionTicketKeyNameLength = 16
+	sessionTicketNonceLength   = 12
+	sessionTicketStateLength   = 11 // cipherSuite, extendedMasterSecret, creation time
+	sessionTicketLifetime      = 7 * 24 * time.Hour
+)
+
+// sessionTicketKey seals the session state carried by a ticket. The name
+// tells which key a ticket was sealed with, so that keys can be rotated.
+// https://tools.ietf.org/html/rfc5077#section-4
+type sessionTicketKey struct {
+	name [sessionTicketKeyNameLength]byte
+	aead cipher.AEAD
+}
+
+// newSessionTicketKeys derives the name and AES-256-GCM key of each of
+// the Config.SessionTicketKeys
+func newSessionTicketKeys(keys [][32]byte) ([]*sessionTicketKey, error) {
+	out := []*sessionTicketKey{}
+	for _, key := range keys {
+		hashed := sha512.Sum512(key[:])
+
+		block, err := aes.NewCipher(hashed[sessionTicketKeyNameLength : sessionTicketKeyNameLength+32])
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+
+		k := &sessionTicketKey{aead: aead}
+		copy(k.name[:], hashed[:sessionTicketKeyNameLength])
+		out = append(out, k)
+	}
+	return out, nil
+}
+
+/*
+encryptSessionTicket seals a Session with the first key
+
+	struct {
+	    opaque key_name[16];
+	    opaque nonce[12];
+	    opaque encrypted_state<0..2^16-1>; // AES-256-GCM, key_name as additional data
+	} ticket;
+
+	struct {
+	    CipherSuite cipher_suite;
+	    uint8 extended_master_secret;
+	    uint64 created; // Unix time
+	    opaque master_secret[48];
+	} state;
+
+The session ID is not part of the state, a resumed session takes the one
+the client offered along with the ticket.
+*/
+func encryptSessionTicket(keys []*sessionTicketKey, s *Session, created time.Time) ([]byte, error) {
+	state := make([]byte, sessionTicketStateLength, sessionTicketStateLength+len(s.Secret))
+	binary.BigEndian.PutUint16(state, uint16(s.CipherSuiteID))
+	if s.ExtendedMasterSecret {
+		state[2] = 1
+	}
+	binary.BigEndian.PutUint64(state[3:], uint64(created.Unix()))
+	state = append(state, s.Secret...)
+
+	key := keys[0]
+	out := make([]byte, sessionTicketKeyNameLength+sessionTicketNonceLength)
+	copy(out, key.name[:])
+	if _, err := rand.Read(out[sessionTicketKeyNameLength:]); err != nil {
+		return nil, err
+	}
+
+	return key.aead.Seal(out, out[sessionTicketKeyNameLength:], state, key.name[:]), nil
+}
+
+// decryptSessionTicket opens a ticket sealed by any of the keys, tickets that
+// are malformed, sealed with an unknown key or expired are ignored
+func decryptSessionTicket(keys []*sessionTicketKey, ticket []byte, now time.Time) (*Session, bool) {
+	if len(ticket) < sessionTicketKeyNameLength+sessionTicketNonceLength {
+		return nil, false
+	}
+
+	for _, key := range keys {
+		if string(key.name[:]) != string(ticket[:sessionTicketKeyNameLength]) {
+			continue
+		}
+
+		nonce := ticket[sessionTicketKeyNameLength : sessionTicketKeyNameLength+sessionTicketNonceLength]
+		state, err := key.aead.Open(nil, nonce, ticket[sessionTicketKeyNameLength+sessionTicketNonceLength:], key.name[:])
+		if err != nil || len(state) <= sessionTicketStateLength {
+			return nil, false
+		}
+
+		created := time.Unix(int64(binary.BigEndian.Uint64(state[3:])), 0)
+		if now.Sub(created) > sessionTicketLifetime {
+			return nil, false
+		}
+
+		return &Session{
+			Secret:               state[sessionTicketStateLength:],
+			CipherSuiteID:        CipherSuiteID(binary.BigEndian.Uint16(state)),
+			ExtendedMasterSecret: state[2] == 1,
+		}, true
+	}
+	return nil, false
+}
@@ -0,0 +1,52 @@
+package dtls
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSessionTicket(t *testing.T) {
+	oldKeys, err := newSessionTicketKeys([][32]byte{{0x01}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := newSessionTicketKeys([][32]byte{{0x02}, {0x01}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session := &Session{
+		Secret:               []byte{0x0A, 0x0B, 0x0C},
+		CipherSuiteID:        TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+		ExtendedMasterSecret: true,
+	}
+	now := time.Unix(1560000000, 0)
+
+	// A ticket sealed before the rotation can still be opened
+	ticket, err := encryptSessionTicket(oldKeys, session, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, ok := decryptSessionTicket(keys, ticket, now.Add(time.Hour)); !ok || !reflect.DeepEqual(s, session) {
+		t.Errorf("decryptSessionTicket: got %#v, want %#v", s, session)
+	}
+
+	if _, ok := decryptSessionTicket(keys, ticket, now.Add(sessionTicketLifetime+time.Second)); ok {
+		t.Error("decryptSessionTicket: expired ticket accepted")
+	}
+
+	ticket[len(ticket)-1] ^= 0x01
+	if _, ok := decryptSessionTicket(keys, ticket, now); ok {
+		t.Error("decryptSessionTicket: tampered ticket accepted")
+	}
+
+	// After the rotation, tickets are sealed with a key unknown to oldKeys
+	ticket, err = encryptSessionTicket(keys, session, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := decryptSessionTicket(oldKeys, ticket, now); ok {
+		t.Error("decryptSessionTicket: ticket sealed with an unknown key accepted")
+	}
+}
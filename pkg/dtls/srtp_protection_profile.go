@@ -1,11 +1,81 @@
 package dtls
 
-type srtpProtectionProfile uint16
+// SRTPProtectionProfile defines the parameters and options that are in effect for the SRTP processing
+// https://tools.ietf.org/html/rfc5764#section-4.1.2
+type SRTPProtectionProfile uint16
 
+// SRTPProtectionProfile enums
+// https://www.iana.org/assignments/srtp-protection/srtp-protection.xhtml
 const (
-	SRTP_AES128_CM_HMAC_SHA1_80 srtpProtectionProfile = 0x0001 // nolint
+	SRTP_AES128_CM_HMAC_SHA1_80 SRTPProtectionProfile = 0x0001 // nolint
+	SRTP_AES128_CM_HMAC_SHA1_32 SRTPProtectionProfile = 0x0002 // nolint
+	SRTP_AEAD_AES_128_GCM       SRTPProtectionProfile = 0x0007 // nolint
+	SRTP_AEAD_AES_256_GCM       SRTPProtectionProfile = 0x0008 // nolint
 )
 
-var srtpProtectionProfiles = map[srtpProtectionProfile]bool{
-	SRTP_AES128_CM_HMAC_SHA1_80: true,
+// srtpProtectionProfiles holds the master key and salt lengths of the
+// known profiles, in bytes
+// https://tools.ietf.org/html/rfc5764#section-4.1.2
+// https://tools.ietf.org/html/rfc7714#section-14.2
+var srtpProtectionProfiles = map[SRTPProtectionProfile]struct{ keyLen, saltLen int }{
+	SRTP_AES128_CM_HMAC_SHA1_80: {16, 14},
+	SRTP_AES128_CM_HMAC_SHA1_32: {16, 14},
+	SRTP_AEAD_AES_128_GCM:       {16, 12},
+	SRTP_AEAD_AES_256_GCM:       {32, 12},
+}
+
+const srtpExporterLabel = "EXTRACTOR-dtls_srtp"
+
+// SRTPKeys holds the SRTP master keys and salts of both sides of a
+// connection, derived from the DTLS master secret
+// https://tools.ietf.org/html/rfc5764#section-4.2
+type SRTPKeys struct {
+	ClientMasterKey, ClientMasterSalt []byte
+	ServerMasterKey, ServerMasterSalt []byte
+}
+
+// SelectedSRTPProtectionProfile returns the profile negotiated with the
+// use_srtp extension, ok is false if the extension wasn't negotiated
+func (c *Conn) SelectedSRTPProtectionProfile() (profile SRTPProtectionProfile, ok bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.srtpProtectionProfile, c.srtpProtectionProfile != 0
+}
+
+// ExportSRTPKeys derives the SRTP master keys and salts of the negotiated
+// profile with the "EXTRACTOR-dtls_srtp" exporter
+func (c *Conn) ExportSRTPKeys() (*SRTPKeys, error) {
+	profile, ok := c.SelectedSRTPProtectionProfile()
+	if !ok {
+		return nil, errNoSRTPProtectionProfile
+	}
+	lengths := srtpProtectionProfiles[profile]
+
+	material, err := c.ExportKeyingMaterial([]byte(srtpExporterLabel), nil, 2*lengths.keyLen+2*lengths.saltLen)
+	if err != nil {
+		return nil, err
+	}
+
+	// client key | server key | client salt | server salt
+	keys := &SRTPKeys{}
+	for _, out := range []*[]byte{&keys.ClientMasterKey, &keys.ServerMasterKey} {
+		*out, material = material[:lengths.keyLen], material[lengths.keyLen:]
+	}
+	for _, out := range []*[]byte{&keys.ClientMasterSalt, &keys.ServerMasterSalt} {
+		*out, material = material[:lengths.saltLen], material[lengths.saltLen:]
+	}
+	return keys, nil
+}
+
+// selectSRTPProtectionProfile returns the first of the local profiles the
+// peer offered, the server's preference wins
+func selectSRTPProtectionProfile(local, remote []SRTPProtectionProfile) (SRTPProtectionProfile, bool) {
+	for _, l := range local {
+		for _, r := range remote {
+			if l == r {
+				return l, true
+			}
+		}
+	}
+	return 0, false
 }
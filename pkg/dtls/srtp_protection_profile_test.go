@@ -0,0 +1,73 @@
+package dtls
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSelectSRTPProtectionProfile(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		local, remote []SRTPProtectionProfile
+		profile       SRTPProtectionProfile
+		ok            bool
+	}{
+		{
+			name:    "local preference wins",
+			local:   []SRTPProtectionProfile{SRTP_AEAD_AES_256_GCM, SRTP_AES128_CM_HMAC_SHA1_80},
+			remote:  []SRTPProtectionProfile{SRTP_AES128_CM_HMAC_SHA1_80, SRTP_AEAD_AES_256_GCM},
+			profile: SRTP_AEAD_AES_256_GCM,
+			ok:      true,
+		},
+		{
+			name:   "no shared profile",
+			local:  []SRTPProtectionProfile{SRTP_AEAD_AES_128_GCM},
+			remote: []SRTPProtectionProfile{SRTP_AES128_CM_HMAC_SHA1_80},
+		},
+		{
+			name:   "not configured",
+			remote: []SRTPProtectionProfile{SRTP_AES128_CM_HMAC_SHA1_80},
+		},
+	} {
+		profile, ok := selectSRTPProtectionProfile(test.local, test.remote)
+		if profile != test.profile || ok != test.ok {
+			t.Errorf("selectSRTPProtectionProfile %s: got %#x %t, want %#x %t", test.name, profile, ok, test.profile, test.ok)
+		}
+	}
+}
+
+func TestExportSRTPKeys(t *testing.T) {
+	var rand [28]byte
+	c := &Conn{
+		localEpoch:   1,
+		isClient:     true,
+		localRandom:  handshakeRandom{time.Unix(500, 0), rand},
+		remoteRandom: handshakeRandom{time.Unix(1000, 0), rand},
+		cipherSuite:  &cipherSuiteTLSEcdheEcdsaWithAes128GcmSha256{},
+		masterSecret: []byte{0x01, 0x02, 0x03},
+	}
+
+	if _, err := c.ExportSRTPKeys(); err != errNoSRTPProtectionProfile {
+		t.Errorf("ExportSRTPKeys without use_srtp: got %v, want %v", err, errNoSRTPProtectionProfile)
+	}
+
+	c.srtpProtectionProfile = SRTP_AEAD_AES_256_GCM
+	material, err := c.ExportKeyingMaterial([]byte("EXTRACTOR-dtls_srtp"), nil, 2*32+2*12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &SRTPKeys{
+		ClientMasterKey:  material[:32],
+		ServerMasterKey:  material[32:64],
+		ClientMasterSalt: material[64:76],
+		ServerMasterSalt: material[76:],
+	}
+
+	keys, err := c.ExportSRTPKeys()
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("ExportSRTPKeys: got %#v, want %#v", keys, expected)
+	}
+}
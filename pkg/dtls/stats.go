@@ -0,0 +1,99 @@
+package dtls
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// connStats are the counters of a Conn, they are accessed atomically
+type connStats struct {
+	recordsSent, recordsReceived uint64
+	bytesSent, bytesReceived     uint64
+	decryptFailures              uint64
+	retransmissions              [flight6]uint64 // indexed by flightVal - 1
+}
+
+// ConnStats is a snapshot of the counters of a Conn
+type ConnStats struct {
+	RecordsSent, RecordsReceived uint64
+
+	// BytesSent and BytesReceived count whole datagrams, headers and
+	// handshake messages included
+	BytesSent, BytesReceived uint64
+
+	// DecryptFailures counts the records discarded because they failed to
+	// decrypt or authenticate
+	DecryptFailures uint64
+
+	Replay ReplayStats
+
+	// Retransmissions counts the flights sent again after a timeout,
+	// indexed by flight number, 0 to 6
+	Retransmissions [7]uint64
+
+	// HandshakeDuration is the time the handshake took, see
+	// ConnectionState
+	HandshakeDuration time.Duration
+}
+
+func (s *ConnStats) add(o ConnStats) {
+	s.RecordsSent += o.RecordsSent
+	s.RecordsReceived += o.RecordsReceived
+	s.BytesSent += o.BytesSent
+	s.BytesReceived += o.BytesReceived
+	s.DecryptFailures += o.DecryptFailures
+	s.Replay.TooOld += o.Replay.TooOld
+	s.Replay.Duplicate += o.Replay.Duplicate
+	for i := range s.Retransmissions {
+		s.Retransmissions[i] += o.Retransmissions[i]
+	}
+	s.HandshakeDuration += o.HandshakeDuration
+}
+
+// Stats returns the counters of the connection
+func (c *Conn) Stats() ConnStats {
+	s := ConnStats{
+		RecordsSent:     atomic.LoadUint64(&c.stats.recordsSent),
+		RecordsReceived: atomic.LoadUint64(&c.stats.recordsReceived),
+		BytesSent:       atomic.LoadUint64(&c.stats.bytesSent),
+		BytesReceived:   atomic.LoadUint64(&c.stats.bytesReceived),
+		DecryptFailures: atomic.LoadUint64(&c.stats.decryptFailures),
+		Replay:          c.ReplayStats(),
+	}
+	for i := range c.stats.retransmissions {
+		s.Retransmissions[i] = atomic.LoadUint64(&c.stats.retransmissions[i])
+	}
+
+	c.lock.RLock()
+	s.HandshakeDuration = c.handshakeDuration
+	c.lock.RUnlock()
+	return s
+}
+
+// ListenerStats is a snapshot of the counters of a Listener
+type ListenerStats struct {
+	// ActiveConnections counts the remotes the socket delivers packets
+	// to, including the ones still handshaking
+	ActiveConnections uint64
+
+	// HelloVerifyRequests counts the ClientHellos answered with a cookie
+	HelloVerifyRequests uint64
+
+	// UnknownCIDDrops counts the records discarded because they carried a
+	// connection ID no connection uses
+	UnknownCIDDrops uint64
+
+	// Migrations counts the times a connection ID was received from a new
+	// address, and the connection moved there
+	Migrations uint64
+
+	// QueueDrops counts the datagrams dropped because the queue of their
+	// connection was full, the connection didn't read them fast enough
+	QueueDrops uint64
+
+	// Handshakes counts the connections the Listener completed the
+	// handshake of, Conns sums their counters. Conns.HandshakeDuration
+	// divided by Handshakes is the mean handshake latency.
+	Handshakes uint64
+	Conns      ConnStats
+}
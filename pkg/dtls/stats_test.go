@@ -0,0 +1,46 @@
+package dtls
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConnStats(t *testing.T) {
+	c := &Conn{
+		replayStats:       ReplayStats{TooOld: 1, Duplicate: 2},
+		stats:             connStats{recordsSent: 3, recordsReceived: 4, bytesSent: 5, bytesReceived: 6, decryptFailures: 7},
+		handshakeDuration: time.Second,
+	}
+	c.stats.retransmissions[flight3-1] = 8
+
+	expected := ConnStats{
+		RecordsSent:       3,
+		RecordsReceived:   4,
+		BytesSent:         5,
+		BytesReceived:     6,
+		DecryptFailures:   7,
+		Replay:            ReplayStats{TooOld: 1, Duplicate: 2},
+		Retransmissions:   [7]uint64{3: 8},
+		HandshakeDuration: time.Second,
+	}
+	stats := c.Stats()
+	if !reflect.DeepEqual(stats, expected) {
+		t.Errorf("Stats: got %#v, want %#v", stats, expected)
+	}
+
+	stats.add(expected)
+	expected = ConnStats{
+		RecordsSent:       6,
+		RecordsReceived:   8,
+		BytesSent:         10,
+		BytesReceived:     12,
+		DecryptFailures:   14,
+		Replay:            ReplayStats{TooOld: 2, Duplicate: 4},
+		Retransmissions:   [7]uint64{3: 16},
+		HandshakeDuration: 2 * time.Second,
+	}
+	if !reflect.DeepEqual(stats, expected) {
+		t.Errorf("Stats add: got %#v, want %#v", stats, expected)
+	}
+}
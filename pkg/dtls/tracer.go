@@ -0,0 +1,111 @@
+package dtls
+
+// Tracer receives the events of the handshake and record layers of a
+// connection, to debug interop problems. Its methods are called
+// synchronously by the goroutines of c, possibly while c is locked: they
+// must return quickly and must not call methods of c other than LocalAddr
+// and RemoteAddr. A Tracer may be shared between connections.
+type Tracer interface {
+	// HandshakeMessage is called for every handshake message sent, and
+	// every handshake fragment received
+	HandshakeMessage(c *Conn, e HandshakeMessageEvent)
+
+	// FlightChanged is called when the handshake moves to a new flight
+	FlightChanged(c *Conn, e FlightEvent)
+
+	// TimerFired is called when the retransmission timer fires, the
+	// current flight is then sent again
+	TimerFired(c *Conn, e TimerEvent)
+
+	// RecordDropped is called for every record received by c that is
+	// discarded without failing the connection. The datagrams a Listener
+	// drops before they reach a Conn are only counted in ListenerStats.
+	RecordDropped(c *Conn, e RecordDroppedEvent)
+}
+
+// HandshakeMessageEvent describes a handshake message, or a fragment of
+// one, as it is found on the wire. Flights are numbered 0 to 6, see
+// flight.go.
+type HandshakeMessageEvent struct {
+	Sent            bool   `json:"sent"`
+	Type            string `json:"type"`
+	Flight          int    `json:"flight"`
+	Epoch           uint16 `json:"epoch"`
+	SequenceNumber  uint64 `json:"sequence_number"`
+	MessageSequence uint16 `json:"message_sequence"`
+	Length          uint32 `json:"length"`
+	FragmentOffset  uint32 `json:"fragment_offset"`
+	FragmentLength  uint32 `json:"fragment_length"`
+}
+
+// FlightEvent describes a change of flight
+type FlightEvent struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// TimerEvent describes a firing of the retransmission timer
+type TimerEvent struct {
+	Flight int `json:"flight"`
+}
+
+// DropReason tells why a record was discarded
+type DropReason string
+
+// DropReason enums
+const (
+	DropOldEpoch         DropReason = "old_epoch"
+	DropHandshakeNotDone DropReason = "handshake_not_finished"
+	DropDecryptFailed    DropReason = "decrypt_failed"
+	DropReplayTooOld     DropReason = "replay_too_old"
+	DropReplayDuplicate  DropReason = "replay_duplicate"
+	DropAfterCloseNotify DropReason = "after_close_notify"
+)
+
+// RecordDroppedEvent describes a discarded record
+type RecordDroppedEvent struct {
+	ContentType    uint8      `json:"content_type"`
+	Epoch          uint16     `json:"epoch"`
+	SequenceNumber uint64     `json:"sequence_number"`
+	Reason         DropReason `json:"reason"`
+}
+
+func (c *Conn) traceHandshakeMessage(sent bool, r *recordLayerHeader, h *handshakeHeader) {
+	if c.tracer == nil {
+		return
+	}
+	c.tracer.HandshakeMessage(c, HandshakeMessageEvent{
+		Sent:            sent,
+		Type:            h.handshakeType.String(),
+		Flight:          int(c.currFlight.get()) - 1,
+		Epoch:           r.epoch,
+		SequenceNumber:  r.sequenceNumber,
+		MessageSequence: h.messageSequence,
+		Length:          h.length,
+		FragmentOffset:  h.fragmentOffset,
+		FragmentLength:  h.fragmentLength,
+	})
+}
+
+func (c *Conn) traceFlightChanged(from, to flightVal) {
+	c.tracer.FlightChanged(c, FlightEvent{From: int(from) - 1, To: int(to) - 1})
+}
+
+func (c *Conn) traceTimerFired() {
+	if c.tracer == nil {
+		return
+	}
+	c.tracer.TimerFired(c, TimerEvent{Flight: int(c.currFlight.get()) - 1})
+}
+
+func (c *Conn) traceRecordDropped(r *recordLayerHeader, reason DropReason) {
+	if c.tracer == nil {
+		return
+	}
+	c.tracer.RecordDropped(c, RecordDroppedEvent{
+		ContentType:    uint8(r.contentType),
+		Epoch:          r.epoch,
+		SequenceNumber: r.sequenceNumber,
+		Reason:         reason,
+	})
+}
@@ -0,0 +1,220 @@
+// Package logging defines the leveled loggers the dtls packages log to,
+// and a default implementation writing to an io.Writer
+package logging
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogLevel is the severity of a log message
+type LogLevel int
+
+// LogLevel enums, a logger at a level prints the messages of that level
+// and the more severe ones
+const (
+	LogLevelDisabled LogLevel = iota
+	LogLevelError
+	LogLevelWarn
+	LogLevelInfo
+	LogLevelDebug
+	LogLevelTrace
+)
+
+var logLevelNames = map[LogLevel]string{
+	LogLevelDisabled: "DISABLED",
+	LogLevelError:    "ERROR",
+	LogLevelWarn:     "WARN",
+	LogLevelInfo:     "INFO",
+	LogLevelDebug:    "DEBUG",
+	LogLevelTrace:    "TRACE",
+}
+
+func (l LogLevel) String() string {
+	if name, ok := logLevelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("LogLevel(%d)", int(l))
+}
+
+// LeveledLogger is the logger the library writes to
+type LeveledLogger interface {
+	Trace(msg string)
+	Tracef(format string, args ...interface{})
+	Debug(msg string)
+	Debugf(format string, args ...interface{})
+	Info(msg string)
+	Infof(format string, args ...interface{})
+	Warn(msg string)
+	Warnf(format string, args ...interface{})
+	Error(msg string)
+	Errorf(format string, args ...interface{})
+}
+
+// LoggerFactory creates the logger of a scope, such as "dtls" or "udp"
+type LoggerFactory interface {
+	NewLogger(scope string) LeveledLogger
+}
+
+// DefaultLoggerFactory creates loggers that write to Writer, each scope at
+// its level in ScopeLevels or at DefaultLevel
+type DefaultLoggerFactory struct {
+	Writer       io.Writer
+	DefaultLevel LogLevel
+	ScopeLevels  map[string]LogLevel
+}
+
+// NewDefaultLoggerFactory returns a DefaultLoggerFactory writing the
+// messages up to level to w
+func NewDefaultLoggerFactory(w io.Writer, level LogLevel) *DefaultLoggerFactory {
+	return &DefaultLoggerFactory{
+		Writer:       w,
+		DefaultLevel: level,
+		ScopeLevels:  map[string]LogLevel{},
+	}
+}
+
+// NewLogger implements LoggerFactory
+func (f *DefaultLoggerFactory) NewLogger(scope string) LeveledLogger {
+	level, ok := f.ScopeLevels[scope]
+	if !ok {
+		level = f.DefaultLevel
+	}
+	return &DefaultLeveledLogger{
+		writer: f.Writer,
+		level:  level,
+		scope:  scope,
+		now:    time.Now,
+	}
+}
+
+// writerLock keeps the lines of loggers sharing a Writer whole
+var writerLock sync.Mutex
+
+// DefaultLeveledLogger writes one line per message, prefixed with the
+// time, the level and the scope
+type DefaultLeveledLogger struct {
+	writer io.Writer
+	level  LogLevel
+	scope  string
+	now    func() time.Time
+}
+
+func (l *DefaultLeveledLogger) logf(level LogLevel, format string, args ...interface{}) {
+	if level > l.level || l.writer == nil {
+		return
+	}
+
+	msg := format
+	if len(args) != 0 {
+		msg = fmt.Sprintf(format, args...)
+	}
+	line := fmt.Sprintf("%s %s %s: %s\n", l.now().Format("2006-01-02T15:04:05.000000Z07:00"), level, l.scope, strings.TrimSuffix(msg, "\n"))
+
+	writerLock.Lock()
+	defer writerLock.Unlock()
+	_, _ = io.WriteString(l.writer, line)
+}
+
+// Trace logs at LogLevelTrace
+func (l *DefaultLeveledLogger) Trace(msg string) { l.logf(LogLevelTrace, "%s", msg) }
+
+// Tracef logs at LogLevelTrace
+func (l *DefaultLeveledLogger) Tracef(format string, args ...interface{}) {
+	l.logf(LogLevelTrace, format, args...)
+}
+
+// Debug logs at LogLevelDebug
+func (l *DefaultLeveledLogger) Debug(msg string) { l.logf(LogLevelDebug, "%s", msg) }
+
+// Debugf logs at LogLevelDebug
+func (l *DefaultLeveledLogger) Debugf(format string, args ...interface{}) {
+	l.logf(LogLevelDebug, format, args...)
+}
+
+// Info logs at LogLevelInfo
+func (l *DefaultLeveledLogger) Info(msg string) { l.logf(LogLevelInfo, "%s", msg) }
+
+// Infof logs at LogLevelInfo
+func (l *DefaultLeveledLogger) Infof(format string, args ...interface{}) {
+	l.logf(LogLevelInfo, format, args...)
+}
+
+// Warn logs at LogLevelWarn
+func (l *DefaultLeveledLogger) Warn(msg string) { l.logf(LogLevelWarn, "%s", msg) }
+
+// Warnf logs at LogLevelWarn
+func (l *DefaultLeveledLogger) Warnf(format string, args ...interface{}) {
+	l.logf(LogLevelWarn, format, args...)
+}
+
+// Error logs at LogLevelError
+func (l *DefaultLeveledLogger) Error(msg string) { l.logf(LogLevelError, "%s", msg) }
+
+// Errorf logs at LogLevelError
+func (l *DefaultLeveledLogger) Errorf(format string, args ...interface{}) {
+	l.logf(LogLevelError, format, args...)
+}
+
+// nopLogger drops every message
+type nopLogger struct{}
+
+func (nopLogger) Trace(string)                  {}
+func (nopLogger) Tracef(string, ...interface{}) {}
+func (nopLogger) Debug(string)                  {}
+func (nopLogger) Debugf(string, ...interface{}) {}
+func (nopLogger) Info(string)                   {}
+func (nopLogger) Infof(string, ...interface{})  {}
+func (nopLogger) Warn(string)                   {}
+func (nopLogger) Warnf(string, ...interface{})  {}
+func (nopLogger) Error(string)                  {}
+func (nopLogger) Errorf(string, ...interface{}) {}
+
+// NewLogger returns the logger of scope from f, or a logger that drops
+// every message if f is nil
+func NewLogger(f LoggerFactory, scope string) LeveledLogger {
+	if f == nil {
+		return nopLogger{}
+	}
+	return f.NewLogger(scope)
+}
+
+// prefixedLogger puts a prefix in front of every message
+type prefixedLogger struct {
+	logger LeveledLogger
+	prefix func() string
+}
+
+// WithPrefix returns a logger that writes to logger, every message starts
+// with the current prefix. It scopes the messages to a connection, the
+// prefix may change during the connection's lifetime.
+func WithPrefix(logger LeveledLogger, prefix func() string) LeveledLogger {
+	if _, ok := logger.(nopLogger); ok {
+		return logger
+	}
+	return &prefixedLogger{logger: logger, prefix: prefix}
+}
+
+func (l *prefixedLogger) Trace(msg string) { l.logger.Trace(l.prefix() + msg) }
+func (l *prefixedLogger) Tracef(format string, args ...interface{}) {
+	l.logger.Trace(l.prefix() + fmt.Sprintf(format, args...))
+}
+func (l *prefixedLogger) Debug(msg string) { l.logger.Debug(l.prefix() + msg) }
+func (l *prefixedLogger) Debugf(format string, args ...interface{}) {
+	l.logger.Debug(l.prefix() + fmt.Sprintf(format, args...))
+}
+func (l *prefixedLogger) Info(msg string) { l.logger.Info(l.prefix() + msg) }
+func (l *prefixedLogger) Infof(format string, args ...interface{}) {
+	l.logger.Info(l.prefix() + fmt.Sprintf(format, args...))
+}
+func (l *prefixedLogger) Warn(msg string) { l.logger.Warn(l.prefix() + msg) }
+func (l *prefixedLogger) Warnf(format string, args ...interface{}) {
+	l.logger.Warn(l.prefix() + fmt.Sprintf(format, args...))
+}
+func (l *prefixedLogger) Error(msg string) { l.logger.Error(l.prefix() + msg) }
+func (l *prefixedLogger) Errorf(format string, args ...interface{}) {
+	l.logger.Error(l.prefix() + fmt.Sprintf(format, args...))
+}
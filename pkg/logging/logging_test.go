@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestDefaultLoggerFactory(t *testing.T) {
+	out := &bytes.Buffer{}
+	f := NewDefaultLoggerFactory(out, LogLevelWarn)
+	f.ScopeLevels["udp"] = LogLevelDebug
+
+	now := func() time.Time { return time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC) }
+	dtls := f.NewLogger("dtls").(*DefaultLeveledLogger)
+	dtls.now = now
+	udp := f.NewLogger("udp").(*DefaultLeveledLogger)
+	udp.now = now
+
+	dtls.Info("dropped by the default level")
+	dtls.Warnf("record %d discarded", 7)
+	udp.Trace("dropped by the scope level")
+	udp.Debug("new connection\n")
+
+	expected := "2019-01-02T03:04:05.000000Z WARN dtls: record 7 discarded\n" +
+		"2019-01-02T03:04:05.000000Z DEBUG udp: new connection\n"
+	if out.String() != expected {
+		t.Errorf("DefaultLoggerFactory: got %q, want %q", out.String(), expected)
+	}
+}
+
+func TestWithPrefix(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := NewDefaultLoggerFactory(out, LogLevelTrace).NewLogger("dtls").(*DefaultLeveledLogger)
+	logger.now = func() time.Time { return time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	prefix := "192.0.2.1:4444: "
+	scoped := WithPrefix(logger, func() string { return prefix })
+	scoped.Errorf("alert %s", "handshake_failure")
+	prefix = "192.0.2.1:4444 cid 0a0b: "
+	scoped.Trace("moved")
+
+	expected := "2019-01-02T03:04:05.000000Z ERROR dtls: 192.0.2.1:4444: alert handshake_failure\n" +
+		"2019-01-02T03:04:05.000000Z TRACE dtls: 192.0.2.1:4444 cid 0a0b: moved\n"
+	if out.String() != expected {
+		t.Errorf("WithPrefix: got %q, want %q", out.String(), expected)
+	}
+
+	// Without a factory nothing is formatted or written
+	if _, ok := WithPrefix(NewLogger(nil, "dtls"), func() string {
+		t.Error("WithPrefix: prefix of a disabled logger evaluated")
+		return ""
+	}).(nopLogger); !ok {
+		t.Error("WithPrefix: disabled logger wrapped")
+	}
+}
@@ -0,0 +1,85 @@
+// Package metrics serves the counters of a dtls.Listener to monitoring
+// systems, as expvar variables or in the Prometheus text format
+package metrics
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/thomas-fossati/dtls/pkg/dtls"
+)
+
+// Expvar returns a variable that reports the stats of l, to be published
+// with expvar.Publish. It is served as JSON by expvar.Handler.
+func Expvar(l *dtls.Listener) expvar.Var {
+	return expvar.Func(func() interface{} {
+		return l.Stats()
+	})
+}
+
+// Handler returns a handler that serves the stats of l in the Prometheus
+// text exposition format, every metric name starts with the dtls_ prefix
+func Handler(l *dtls.Listener) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = WritePrometheus(w, l.Stats())
+	})
+}
+
+// WritePrometheus writes s to w in the Prometheus text exposition format
+func WritePrometheus(w io.Writer, s dtls.ListenerStats) error {
+	p := &promWriter{w: w}
+	p.metric("dtls_active_connections", "gauge", "Remotes the listener has an open connection with.", s.ActiveConnections)
+	p.metric("dtls_hello_verify_requests_total", "counter", "ClientHellos answered with a HelloVerifyRequest.", s.HelloVerifyRequests)
+	p.metric("dtls_unknown_cid_drops_total", "counter", "Records dropped for carrying an unknown connection ID.", s.UnknownCIDDrops)
+	p.metric("dtls_migrations_total", "counter", "Connections that moved to a new remote address.", s.Migrations)
+	p.metric("dtls_queue_drops_total", "counter", "Datagrams dropped because the queue of their connection was full.", s.QueueDrops)
+
+	p.header("dtls_handshake_duration_seconds", "summary", "Duration of the completed handshakes.")
+	p.value("dtls_handshake_duration_seconds_sum", "", s.Conns.HandshakeDuration.Seconds())
+	p.value("dtls_handshake_duration_seconds_count", "", s.Handshakes)
+
+	p.metric("dtls_records_sent_total", "counter", "Records sent.", s.Conns.RecordsSent)
+	p.metric("dtls_records_received_total", "counter", "Records received.", s.Conns.RecordsReceived)
+	p.metric("dtls_bytes_sent_total", "counter", "Bytes sent, record headers included.", s.Conns.BytesSent)
+	p.metric("dtls_bytes_received_total", "counter", "Bytes received, record headers included.", s.Conns.BytesReceived)
+	p.metric("dtls_decrypt_failures_total", "counter", "Records dropped because they failed to decrypt.", s.Conns.DecryptFailures)
+
+	p.header("dtls_replays_dropped_total", "counter", "Records dropped by replay protection.")
+	p.value("dtls_replays_dropped_total", `{reason="too_old"}`, s.Conns.Replay.TooOld)
+	p.value("dtls_replays_dropped_total", `{reason="duplicate"}`, s.Conns.Replay.Duplicate)
+
+	p.header("dtls_retransmissions_total", "counter", "Handshake flights sent again, by flight number.")
+	for i, n := range s.Conns.Retransmissions {
+		p.value("dtls_retransmissions_total", fmt.Sprintf(`{flight="%d"}`, i), n)
+	}
+	return p.err
+}
+
+// promWriter keeps the first error, so that the metrics can be written
+// without checking each of them
+type promWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (p *promWriter) printf(format string, a ...interface{}) {
+	if p.err == nil {
+		_, p.err = fmt.Fprintf(p.w, format, a...)
+	}
+}
+
+func (p *promWriter) header(name, typ, help string) {
+	p.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func (p *promWriter) value(name, labels string, v interface{}) {
+	p.printf("%s%s %v\n", name, labels, v)
+}
+
+func (p *promWriter) metric(name, typ, help string, v uint64) {
+	p.header(name, typ, help)
+	p.value(name, "", v)
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thomas-fossati/dtls/pkg/dtls"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	s := dtls.ListenerStats{
+		ActiveConnections:   2,
+		HelloVerifyRequests: 3,
+		UnknownCIDDrops:     4,
+		Migrations:          5,
+		QueueDrops:          11,
+		Handshakes:          6,
+		Conns: dtls.ConnStats{
+			RecordsSent:       7,
+			BytesReceived:     8,
+			Replay:            dtls.ReplayStats{Duplicate: 9},
+			Retransmissions:   [7]uint64{5: 10},
+			HandshakeDuration: 1500 * time.Millisecond,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WritePrometheus(&buf, s); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"# TYPE dtls_active_connections gauge\ndtls_active_connections 2\n",
+		"# TYPE dtls_hello_verify_requests_total counter\ndtls_hello_verify_requests_total 3\n",
+		"\ndtls_unknown_cid_drops_total 4\n",
+		"\ndtls_migrations_total 5\n",
+		"\ndtls_queue_drops_total 11\n",
+		"\ndtls_handshake_duration_seconds_sum 1.5\ndtls_handshake_duration_seconds_count 6\n",
+		"\ndtls_records_sent_total 7\n",
+		"\ndtls_records_received_total 0\n",
+		"\ndtls_bytes_received_total 8\n",
+		"\ndtls_replays_dropped_total{reason=\"too_old\"} 0\ndtls_replays_dropped_total{reason=\"duplicate\"} 9\n",
+		"\ndtls_retransmissions_total{flight=\"5\"} 10\n",
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("WritePrometheus: %q not found in\n%s", expected, buf.String())
+		}
+	}
+}